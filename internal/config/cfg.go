@@ -2,54 +2,490 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func getHomeDir() (string, error) {
-	homeDir, err := os.UserHomeDir()
+// setting describes one config key, and how it is read from and written to
+// a Config.
+type setting struct {
+	key string
+	env []string
+	// profile is set for the keys each profile has its own value of
+	profile bool
+	get     func(c *Config) string
+	set     func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{
+		key: "db_url",
+		// GOOSE_DBSTRING is what older setups and the goose CLI use
+		env:     []string{"GATOR_DB_URL", "GOOSE_DBSTRING"},
+		profile: true,
+		get:     func(c *Config) string { return c.DbUrl },
+		set:     func(c *Config, value string) error { c.DbUrl = value; return nil },
+	},
+	{
+		key:     "current_user_name",
+		env:     []string{"GATOR_CURRENT_USER_NAME"},
+		profile: true,
+		get:     func(c *Config) string { return c.CurrentUserName },
+		set:     func(c *Config, value string) error { c.CurrentUserName = value; return nil },
+	},
+	{
+		key: "agg.workers",
+		env: []string{"GATOR_AGG_WORKERS"},
+		get: func(c *Config) string { return formatInt(c.Agg.Workers) },
+		set: func(c *Config, value string) error { return parsePositive(value, &c.Agg.Workers) },
+	},
+	{
+		key: "agg.per_host",
+		env: []string{"GATOR_AGG_PER_HOST"},
+		get: func(c *Config) string { return formatInt(c.Agg.PerHost) },
+		set: func(c *Config, value string) error { return parsePositive(value, &c.Agg.PerHost) },
+	},
+	{
+		key: "agg.fetch_timeout",
+		env: []string{"GATOR_AGG_FETCH_TIMEOUT"},
+		get: func(c *Config) string { return formatDuration(c.Agg.FetchTimeout) },
+		set: func(c *Config, value string) error { return parseDuration(value, &c.Agg.FetchTimeout) },
+	},
+	{
+		key: "agg.shutdown_timeout",
+		env: []string{"GATOR_AGG_SHUTDOWN_TIMEOUT"},
+		get: func(c *Config) string { return formatDuration(c.Agg.ShutdownTimeout) },
+		set: func(c *Config, value string) error { return parseDuration(value, &c.Agg.ShutdownTimeout) },
+	},
+	{
+		key: "agg.user_agent",
+		env: []string{"GATOR_AGG_USER_AGENT"},
+		get: func(c *Config) string { return c.Agg.UserAgent },
+		set: func(c *Config, value string) error { c.Agg.UserAgent = value; return nil },
+	},
+}
+
+// Defaults is the config used for every key the file and environment
+// leave unset.
+func Defaults() Config {
+	return Config{
+		Agg: AggConfig{
+			Workers:         4,
+			PerHost:         2,
+			FetchTimeout:    Duration{30 * time.Second},
+			ShutdownTimeout: Duration{10 * time.Second},
+			UserAgent:       "gator",
+		},
+	}
+}
+
+// Path picks the config file: override when given, then GATOR_CONFIG, then
+// $XDG_CONFIG_HOME/gator/config.json, unless only the legacy
+// ~/.gatorconfig.json exists.
+func Path(override string) (string, error) {
+	if override != "" {
+		return override, nil
+	}
+	if path := os.Getenv(ConfigPathEnv); path != "" {
+		return path, nil
+	}
+
+	configDir, err := os.UserConfigDir()
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("Error finding config directory:\n%v\n", err)
+	}
+	path := filepath.Join(configDir, configDirName, configFile)
+	if fileExists(path) {
+		return path, nil
 	}
 
-	return homeDir, nil
+	homeDir, err := os.UserHomeDir()
+	if err == nil && fileExists(filepath.Join(homeDir, legacyConfigFile)) {
+		return filepath.Join(homeDir, legacyConfigFile), nil
+	}
+	return path, nil
 }
 
-func Read() (Config, error) {
-	homeDir, err := getHomeDir()
-	if err != nil {
-		fmt.Println("Error finding home directory")
+// Load reads the config at path and layers the environment over the file
+// and the file over the defaults. profile picks the profile to use, when
+// empty GATOR_PROFILE and then the file decide. A missing file reads as
+// empty, it is only created once a value is saved.
+//
+// A profile picked with profile or GATOR_PROFILE is used as saved: its
+// db_url and current_user_name aren't overridden by the environment, which
+// would otherwise point the command at another database.
+func Load(path string, profile string) (Config, error) {
+	file, err := readFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return Config{}, err
 	}
 
-	data, err := os.ReadFile(homeDir + "/" + configFilePath)
+	name := profile
+	if name == "" {
+		name = os.Getenv(ProfileEnv)
+	}
+	picked := name != ""
+	if name == "" {
+		name = file.CurrentProfile
+	}
+	if name == "" {
+		name = DefaultProfile
+	}
+	layer, err := file.withProfile(name)
 	if err != nil {
-		fmt.Println("Error reading from file")
 		return Config{}, err
 	}
 
-	var cfg Config
-	err = json.Unmarshal(data, &cfg)
+	cfg := Defaults()
+	cfg.path = path
+	cfg.profile = name
+	cfg.override = profile
+	cfg.file = &file
+	cfg.sources = map[string]string{}
+	for _, s := range settings {
+		cfg.sources[s.key] = SourceDefault
+		if value := s.get(&layer); value != "" {
+			err = s.set(&cfg, value)
+			if err != nil {
+				return Config{}, fmt.Errorf("Invalid %v in config file %v: %v", s.key, path, err)
+			}
+			cfg.sources[s.key] = SourceFile
+		}
+		if s.profile && picked {
+			continue
+		}
+		if name, value, ok := lookupEnv(s.env); ok {
+			err = s.set(&cfg, value)
+			if err != nil {
+				return Config{}, fmt.Errorf("Invalid %v: %v", name, err)
+			}
+			cfg.sources[s.key] = SourceEnv
+		}
+	}
+	return cfg, nil
+}
+
+// Keys lists the config keys, in the order list shows them.
+func Keys() []string {
+	keys := []string{}
+	for _, s := range settings {
+		keys = append(keys, s.key)
+	}
+	return keys
+}
+
+// Path returns the file the config is saved to.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Get returns the effective value of key and where it came from.
+func (c *Config) Get(key string) (value string, source string, err error) {
+	s, err := lookupSetting(key)
 	if err != nil {
-		return Config{}, err
+		return "", "", err
 	}
+	return s.get(c), c.sources[key], nil
+}
 
-	return cfg, nil
+// Set saves key to the config file, an empty value removes it. db_url and
+// current_user_name are saved to the profile in use. The effective value
+// only changes when the environment doesn't override it.
+func (c *Config) Set(key, value string) error {
+	s, err := lookupSetting(key)
+	if err != nil {
+		return err
+	}
+
+	return c.update(func(file *Config) error {
+		layer, err := file.withProfile(c.profile)
+		if err != nil {
+			return err
+		}
+		err = s.set(&layer, value)
+		if err != nil {
+			return fmt.Errorf("Invalid value for %v: %v", key, err)
+		}
+		file.storeProfile(c.profile, layer)
+		return nil
+	})
+}
+
+// Profile returns the name of the profile in use.
+func (c *Config) Profile() string {
+	return c.profile
+}
+
+// ProfileNames lists the profiles in the file, the default one first.
+func (c *Config) ProfileNames() []string {
+	names := []string{}
+	if c.file != nil {
+		for name := range c.file.Profiles {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...)
+}
+
+// LookupProfile returns a profile as saved in the file.
+func (c *Config) LookupProfile(name string) (Profile, bool) {
+	if c.file == nil {
+		return Profile{}, false
+	}
+	layer, err := c.file.withProfile(name)
+	if err != nil {
+		return Profile{}, false
+	}
+	return Profile{DbUrl: layer.DbUrl, CurrentUserName: layer.CurrentUserName}, true
 }
 
-func Write(config Config) error {
-	byteData, err := json.Marshal(config)
+// AddProfile saves a new profile to the file.
+func (c *Config) AddProfile(name string, profile Profile) error {
+	err := checkProfileName(name)
 	if err != nil {
 		return err
 	}
-	homeDir, err := getHomeDir()
+
+	return c.update(func(file *Config) error {
+		if _, err := file.withProfile(name); err == nil {
+			return fmt.Errorf("Profile '%v' already exists", name)
+		}
+		if file.Profiles == nil {
+			file.Profiles = map[string]Profile{}
+		}
+		file.Profiles[name] = profile
+		return nil
+	})
+}
+
+// RemoveProfile deletes a profile from the file. Neither the default profile
+// nor the one the file switched to can be removed.
+func (c *Config) RemoveProfile(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("The default profile can't be removed")
+	}
+
+	return c.update(func(file *Config) error {
+		if _, exists := file.Profiles[name]; !exists {
+			return fmt.Errorf("Profile '%v' does not exist", name)
+		}
+		if file.CurrentProfile == name {
+			return fmt.Errorf("Profile '%v' is in use, switch to another one first", name)
+		}
+		delete(file.Profiles, name)
+		return nil
+	})
+}
+
+// UseProfile makes name the profile used when none is picked explicitly.
+func (c *Config) UseProfile(name string) error {
+	return c.update(func(file *Config) error {
+		if _, err := file.withProfile(name); err != nil {
+			return err
+		}
+		file.CurrentProfile = name
+		if name == DefaultProfile {
+			file.CurrentProfile = ""
+		}
+		return nil
+	})
+}
+
+// update applies change to the file as it is on disk now, so values saved
+// by other invocations in the meantime are kept, writes it back and reloads.
+func (c *Config) update(change func(file *Config) error) error {
+	if c.path == "" {
+		return fmt.Errorf("Config wasn't loaded from a file")
+	}
+
+	file, err := readFile(c.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	err = change(&file)
 	if err != nil {
 		return err
 	}
+	err = write(c.path, file)
+	if err != nil {
+		return fmt.Errorf("Error saving config file %v:\n%v\n", c.path, err)
+	}
 
-	err = os.WriteFile(homeDir+"/"+configFilePath, byteData, 0600)
+	// Layer the environment and defaults over the new file again
+	reloaded, err := Load(c.path, c.override)
 	if err != nil {
 		return err
 	}
+	*c = reloaded
+	return nil
+}
+
+// withProfile returns the file with the profile's values at the top level,
+// where the settings read them from.
+func (c Config) withProfile(name string) (Config, error) {
+	if name == DefaultProfile {
+		return c, nil
+	}
+	profile, exists := c.Profiles[name]
+	if !exists {
+		return Config{}, fmt.Errorf("Profile '%v' does not exist", name)
+	}
+	c.DbUrl = profile.DbUrl
+	c.CurrentUserName = profile.CurrentUserName
+	return c, nil
+}
+
+// storeProfile is the reverse of withProfile, it moves the profile's values
+// of layer back into the profile.
+func (c *Config) storeProfile(name string, layer Config) {
+	if name != DefaultProfile {
+		c.Profiles[name] = Profile{DbUrl: layer.DbUrl, CurrentUserName: layer.CurrentUserName}
+		layer.DbUrl, layer.CurrentUserName = c.DbUrl, c.CurrentUserName
+	}
+	*c = layer
+}
+
+func checkProfileName(name string) error {
+	if name == "" || name == DefaultProfile {
+		return fmt.Errorf("Invalid profile name '%v'", name)
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.", r)) {
+			return fmt.Errorf("Invalid profile name '%v', use letters, digits, '-', '_' and '.'", name)
+		}
+	}
+	return nil
+}
+
+func readFile(path string) (Config, error) {
+	file := Config{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, err
+	}
+	if err != nil {
+		return file, fmt.Errorf("Error reading config file %v:\n%v\n", path, err)
+	}
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return file, fmt.Errorf("Error parsing config file %v:\n%v\n", path, err)
+	}
+	return file, nil
+}
+
+// write replaces the file at path atomically: a concurrent reader sees
+// either the old or the new config, never a partial one.
+func write(path string, cfg Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	// The temporary file has to be in the same directory for the rename
+	// to be atomic
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(append(data, '\n'))
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func lookupSetting(key string) (setting, error) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, nil
+		}
+	}
+	keys := Keys()
+	sort.Strings(keys)
+	return setting{}, fmt.Errorf("Unknown config key '%v'. Expected one of %v", key, strings.Join(keys, ", "))
+}
+
+// lookupEnv returns the first of names that is set.
+func lookupEnv(names []string) (string, string, bool) {
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			return name, value, true
+		}
+	}
+	return "", "", false
+}
+
+// EnvNames returns the environment variables that override key.
+func EnvNames(key string) []string {
+	s, err := lookupSetting(key)
+	if err != nil {
+		return nil
+	}
+	return s.env
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func formatInt(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
+
+func formatDuration(d Duration) string {
+	if d.Duration == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+func parsePositive(value string, target *int) error {
+	if value == "" {
+		*target = 0
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return fmt.Errorf("'%v' is not a positive number", value)
+	}
+	*target = n
+	return nil
+}
+
+func parseDuration(value string, target *Duration) error {
+	if value == "" {
+		target.Duration = 0
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("'%v' is not a positive duration", value)
+	}
+	target.Duration = d
 	return nil
 }
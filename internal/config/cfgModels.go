@@ -1,27 +1,133 @@
 package config
 
-import "github.com/wfcornelissen/blogag/internal/database"
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/wfcornelissen/blogag/internal/database"
+	"github.com/wfcornelissen/blogag/internal/migrate"
+)
 
 type Config struct {
-	DbUrl           string `json:"db_url"`
-	CurrentUserName string `json:"current_user_name"`
+	DbUrl           string    `json:"db_url,omitempty"`
+	CurrentUserName string    `json:"current_user_name,omitempty"`
+	Agg             AggConfig `json:"agg,omitzero"`
+	// CurrentProfile is used when no profile is picked with --profile or
+	// GATOR_PROFILE, empty for the default profile
+	CurrentProfile string             `json:"current_profile,omitempty"`
+	Profiles       map[string]Profile `json:"profiles,omitempty"`
+
+	// path is the file the config was loaded from and is saved to
+	path string
+	// profile is the profile in use, override the one asked for in Load
+	profile  string
+	override string
+	// file holds only the values read from path, so saving never writes
+	// environment overrides or defaults into it
+	file *Config
+	// sources records where each effective value came from
+	sources map[string]string
 }
 
-func (c *Config) SetUser(user string) error {
-	err := Write(Config{
-		DbUrl:           c.DbUrl,
-		CurrentUserName: user,
-	})
+// Profile is a database and the user logged in to it. The top level
+// db_url and current_user_name are the default profile.
+type Profile struct {
+	DbUrl           string `json:"db_url,omitempty"`
+	CurrentUserName string `json:"current_user_name,omitempty"`
+}
+
+// AggConfig holds the aggregator tunables.
+type AggConfig struct {
+	Workers         int      `json:"workers,omitempty"`
+	PerHost         int      `json:"per_host,omitempty"`
+	FetchTimeout    Duration `json:"fetch_timeout,omitzero"`
+	ShutdownTimeout Duration `json:"shutdown_timeout,omitzero"`
+	UserAgent       string   `json:"user_agent,omitempty"`
+}
+
+// Duration is a time.Duration written as "30s" rather than nanoseconds.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	err := json.Unmarshal(data, &value)
 	if err != nil {
 		return err
 	}
+	d.Duration, err = time.ParseDuration(value)
+	return err
+}
 
-	return nil
+// SetUser switches the current user and saves it to the config file.
+func (c *Config) SetUser(user string) error {
+	return c.Set("current_user_name", user)
 }
 
 type State struct {
-	Db    *database.Queries
+	// Db is the queries for Postgres or SQLite in gator, and an in-memory
+	// store in tests
+	Db    database.Querier
 	State *Config
+	// Conn is the pool behind Db, used to run migrations
+	Conn *sql.DB
+	// Schema is the migrations for the kind of database Conn is
+	Schema migrate.Schema
+	// WithTx returns the queries of Db run in a transaction on Conn, nil
+	// for a store without transactions
+	WithTx func(tx *sql.Tx) database.Querier
 }
 
-const configFilePath = ".gatorconfig.json"
+// InTx runs fn with queries in a single transaction, which is committed
+// when fn succeeds and rolled back otherwise. Without transactions fn runs
+// on Db directly.
+func (s *State) InTx(ctx context.Context, fn func(db database.Querier) error) error {
+	if s.Conn == nil || s.WithTx == nil {
+		return fn(s.Db)
+	}
+
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Failed to start transaction:\n%v\n", err)
+	}
+	err = fn(s.WithTx(tx))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("Failed to commit transaction:\n%v\n", err)
+	}
+	return nil
+}
+
+// Where a value came from, from lowest to highest precedence. Command line
+// flags rank above all of them, commands apply those themselves.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+const (
+	configDirName = "gator"
+	configFile    = "config.json"
+	// legacyConfigFile in the home directory is still used when it exists
+	// and the XDG config doesn't
+	legacyConfigFile = ".gatorconfig.json"
+	// ConfigPathEnv points at the config file, like --config
+	ConfigPathEnv = "GATOR_CONFIG"
+	// ProfileEnv picks the profile, like --profile
+	ProfileEnv = "GATOR_PROFILE"
+	// DefaultProfile is the name of the profile stored at the top level
+	DefaultProfile = "default"
+)
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPickedProfileWinsOverEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := write(path, Config{
+		DbUrl:           "postgres://localhost/personal",
+		CurrentUserName: "alice",
+		Profiles: map[string]Profile{
+			"team": {DbUrl: "postgres://db.example.com/team", CurrentUserName: "bob"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(ProfileEnv, "")
+	t.Setenv("GATOR_DB_URL", "")
+	t.Setenv("GOOSE_DBSTRING", "postgres://localhost/env")
+	t.Setenv("GATOR_CURRENT_USER_NAME", "carol")
+
+	// Without a picked profile the environment overrides the file
+	cfg, err := Load(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DbUrl != "postgres://localhost/env" || cfg.CurrentUserName != "carol" {
+		t.Errorf("default profile = %v as %v, want the environment's", cfg.DbUrl, cfg.CurrentUserName)
+	}
+
+	check := func(cfg Config) {
+		t.Helper()
+		if cfg.DbUrl != "postgres://db.example.com/team" || cfg.CurrentUserName != "bob" {
+			t.Errorf("team profile = %v as %v, want the saved one", cfg.DbUrl, cfg.CurrentUserName)
+		}
+		if _, source, _ := cfg.Get("db_url"); source != SourceFile {
+			t.Errorf("db_url comes from %v, want %v", source, SourceFile)
+		}
+	}
+
+	cfg, err = Load(path, "team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(cfg)
+
+	t.Setenv(ProfileEnv, "team")
+	cfg, err = Load(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(cfg)
+}
+
+func TestInvalidFileValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{"agg": {"workers": -1}}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GATOR_AGG_WORKERS", "")
+
+	_, err = Load(path, "")
+	if err == nil || !strings.Contains(err.Error(), path) || !strings.Contains(err.Error(), "agg.workers") {
+		t.Errorf("Load = %v, want an error naming %v and agg.workers", err, path)
+	}
+}
+
+func TestLoadLeavesMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gator", "config.json")
+	for _, name := range []string{ProfileEnv, "GATOR_DB_URL", "GOOSE_DBSTRING", "GATOR_CURRENT_USER_NAME"} {
+		t.Setenv(name, "")
+	}
+
+	cfg, err := Load(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileExists(path) {
+		t.Fatalf("Load created %v", path)
+	}
+
+	err = cfg.SetUser("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := Load(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.CurrentUserName != "alice" {
+		t.Errorf("saved current user = %q, want alice", saved.CurrentUserName)
+	}
+}
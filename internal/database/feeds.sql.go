@@ -12,26 +12,96 @@ import (
 	"github.com/google/uuid"
 )
 
+const claimFeedsToFetch = `-- name: ClaimFeedsToFetch :many
+WITH claimed AS (
+    SELECT id FROM feeds
+    WHERE next_fetch_at IS NULL OR next_fetch_at <= $1
+    ORDER BY next_fetch_at ASC NULLS FIRST
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE feeds SET last_fetched_at = $1, next_fetch_at = $3
+FROM claimed
+WHERE feeds.id = claimed.id
+RETURNING feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.etag, feeds.last_modified, feeds.last_error, feeds.last_error_status, feeds.last_error_at, feeds.consecutive_failures, feeds.next_fetch_at, feeds.min_fetch_interval, feeds.skip_hours, feeds.skip_days, feeds.description, feeds.site_url, feeds.icon_url
+`
+
+type ClaimFeedsToFetchParams struct {
+	Now          sql.NullTime
+	BatchSize    int32
+	ClaimedUntil sql.NullTime
+}
+
+func (q *Queries) ClaimFeedsToFetch(ctx context.Context, arg ClaimFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, claimFeedsToFetch, arg.Now, arg.BatchSize, arg.ClaimedUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.LastError,
+			&i.LastErrorStatus,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+			&i.NextFetchAt,
+			&i.MinFetchInterval,
+			&i.SkipHours,
+			&i.SkipDays,
+			&i.Description,
+			&i.SiteUrl,
+			&i.IconUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, description, site_url, icon_url)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8,
+    $9
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, last_error, last_error_status, last_error_at, consecutive_failures, next_fetch_at, min_fetch_interval, skip_hours, skip_days, description, site_url, icon_url
 `
 
 type CreateFeedParams struct {
-	ID        uuid.UUID
-	CreatedAt sql.NullTime
-	UpdatedAt sql.NullTime
-	Name      sql.NullString
-	Url       sql.NullString
-	UserID    uuid.UUID
+	ID          uuid.UUID
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Name        sql.NullString
+	Url         sql.NullString
+	UserID      uuid.UUID
+	Description sql.NullString
+	SiteUrl     sql.NullString
+	IconUrl     sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -42,6 +112,9 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.Description,
+		arg.SiteUrl,
+		arg.IconUrl,
 	)
 	var i Feed
 	err := row.Scan(
@@ -52,6 +125,19 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LastError,
+		&i.LastErrorStatus,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.NextFetchAt,
+		&i.MinFetchInterval,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.Description,
+		&i.SiteUrl,
+		&i.IconUrl,
 	)
 	return i, err
 }
@@ -89,8 +175,88 @@ func (q *Queries) GetAllFeeds(ctx context.Context) ([]GetAllFeedsRow, error) {
 	return items, nil
 }
 
+const getFailingFeeds = `-- name: GetFailingFeeds :many
+SELECT name, url, last_fetched_at, last_error, last_error_status, last_error_at, consecutive_failures
+FROM feeds
+WHERE consecutive_failures > 0
+ORDER BY consecutive_failures DESC, name ASC
+`
+
+type GetFailingFeedsRow struct {
+	Name                sql.NullString
+	Url                 sql.NullString
+	LastFetchedAt       sql.NullTime
+	LastError           sql.NullString
+	LastErrorStatus     sql.NullInt32
+	LastErrorAt         sql.NullTime
+	ConsecutiveFailures int32
+}
+
+func (q *Queries) GetFailingFeeds(ctx context.Context) ([]GetFailingFeedsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFailingFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFailingFeedsRow
+	for rows.Next() {
+		var i GetFailingFeedsRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.LastFetchedAt,
+			&i.LastError,
+			&i.LastErrorStatus,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedByName = `-- name: GetFeedByName :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, last_error, last_error_status, last_error_at, consecutive_failures, next_fetch_at, min_fetch_interval, skip_hours, skip_days, description, site_url, icon_url from feeds WHERE name = $1
+`
+
+func (q *Queries) GetFeedByName(ctx context.Context, name sql.NullString) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByName, name)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LastError,
+		&i.LastErrorStatus,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.NextFetchAt,
+		&i.MinFetchInterval,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.Description,
+		&i.SiteUrl,
+		&i.IconUrl,
+	)
+	return i, err
+}
+
 const getFeedByURL = `-- name: GetFeedByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at from feeds WHERE url = $1
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, last_error, last_error_status, last_error_at, consecutive_failures, next_fetch_at, min_fetch_interval, skip_hours, skip_days, description, site_url, icon_url from feeds WHERE url = $1
 `
 
 func (q *Queries) GetFeedByURL(ctx context.Context, url sql.NullString) (Feed, error) {
@@ -104,13 +270,26 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url sql.NullString) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LastError,
+		&i.LastErrorStatus,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.NextFetchAt,
+		&i.MinFetchInterval,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.Description,
+		&i.SiteUrl,
+		&i.IconUrl,
 	)
 	return i, err
 }
 
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, last_error, last_error_status, last_error_at, consecutive_failures, next_fetch_at, min_fetch_interval, skip_hours, skip_days, description, site_url, icon_url FROM feeds
+ORDER BY next_fetch_at ASC NULLS FIRST
 LIMIT 1
 `
 
@@ -125,6 +304,19 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LastError,
+		&i.LastErrorStatus,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.NextFetchAt,
+		&i.MinFetchInterval,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.Description,
+		&i.SiteUrl,
+		&i.IconUrl,
 	)
 	return i, err
 }
@@ -142,3 +334,92 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams
 	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.LastFetchedAt, arg.Url)
 	return err
 }
+
+const recordFeedFailure = `-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET last_error = $1,
+    last_error_status = $2,
+    last_error_at = $3,
+    consecutive_failures = consecutive_failures + 1
+WHERE id = $4
+`
+
+type RecordFeedFailureParams struct {
+	LastError       sql.NullString
+	LastErrorStatus sql.NullInt32
+	LastErrorAt     sql.NullTime
+	ID              uuid.UUID
+}
+
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedFailure,
+		arg.LastError,
+		arg.LastErrorStatus,
+		arg.LastErrorAt,
+		arg.ID,
+	)
+	return err
+}
+
+const recordFeedSuccess = `-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET last_error = NULL,
+    last_error_status = NULL,
+    consecutive_failures = 0
+WHERE id = $1
+`
+
+func (q *Queries) RecordFeedSuccess(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, recordFeedSuccess, id)
+	return err
+}
+
+const setFeedNextFetchAt = `-- name: SetFeedNextFetchAt :exec
+UPDATE feeds SET next_fetch_at = $1 WHERE id = $2
+`
+
+type SetFeedNextFetchAtParams struct {
+	NextFetchAt sql.NullTime
+	ID          uuid.UUID
+}
+
+func (q *Queries) SetFeedNextFetchAt(ctx context.Context, arg SetFeedNextFetchAtParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedNextFetchAt, arg.NextFetchAt, arg.ID)
+	return err
+}
+
+const updateFeedCacheHeaders = `-- name: UpdateFeedCacheHeaders :exec
+UPDATE feeds SET etag = $1, last_modified = $2 WHERE id = $3
+`
+
+type UpdateFeedCacheHeadersParams struct {
+	Etag         sql.NullString
+	LastModified sql.NullString
+	ID           uuid.UUID
+}
+
+func (q *Queries) UpdateFeedCacheHeaders(ctx context.Context, arg UpdateFeedCacheHeadersParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCacheHeaders, arg.Etag, arg.LastModified, arg.ID)
+	return err
+}
+
+const updateFeedScheduleHints = `-- name: UpdateFeedScheduleHints :exec
+UPDATE feeds SET min_fetch_interval = $1, skip_hours = $2, skip_days = $3 WHERE id = $4
+`
+
+type UpdateFeedScheduleHintsParams struct {
+	MinFetchInterval sql.NullInt32
+	SkipHours        int32
+	SkipDays         int32
+	ID               uuid.UUID
+}
+
+func (q *Queries) UpdateFeedScheduleHints(ctx context.Context, arg UpdateFeedScheduleHintsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedScheduleHints,
+		arg.MinFetchInterval,
+		arg.SkipHours,
+		arg.SkipDays,
+		arg.ID,
+	)
+	return err
+}
@@ -12,13 +12,26 @@ import (
 )
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     sql.NullTime
-	UpdatedAt     sql.NullTime
-	Name          sql.NullString
-	Url           sql.NullString
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                  uuid.UUID
+	CreatedAt           sql.NullTime
+	UpdatedAt           sql.NullTime
+	Name                sql.NullString
+	Url                 sql.NullString
+	UserID              uuid.UUID
+	LastFetchedAt       sql.NullTime
+	Etag                sql.NullString
+	LastModified        sql.NullString
+	LastError           sql.NullString
+	LastErrorStatus     sql.NullInt32
+	LastErrorAt         sql.NullTime
+	ConsecutiveFailures int32
+	NextFetchAt         sql.NullTime
+	MinFetchInterval    sql.NullInt32
+	SkipHours           int32
+	SkipDays            int32
+	Description         sql.NullString
+	SiteUrl             sql.NullString
+	IconUrl             sql.NullString
 }
 
 type FeedFollow struct {
@@ -27,17 +40,41 @@ type FeedFollow struct {
 	UpdatedAt sql.NullTime
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Category  sql.NullString
 }
 
 type Post struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  sql.NullTime
+	FeedID       uuid.NullUUID
+	Content      sql.NullString
+	SearchVector interface{}
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type Star struct {
 	ID          uuid.UUID
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	PostID      uuid.NullUUID
 	Title       string
 	Url         string
 	Description sql.NullString
 	PublishedAt sql.NullTime
-	FeedID      uuid.NullUUID
+	FeedName    sql.NullString
+	Note        sql.NullString
+	Content     sql.NullString
 }
 
 type User struct {
@@ -13,8 +13,99 @@ import (
 	"github.com/google/uuid"
 )
 
-const createPost = `-- name: CreatePost :exec
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+const browsePosts = `-- name: BrowsePosts :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content
+FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1
+AND ($2::uuid IS NULL OR posts.feed_id = $2::uuid)
+AND ($3::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) >= $3::timestamp)
+AND ($4::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < $4::timestamp)
+AND (
+    $5::timestamp IS NULL
+    OR (posts.published_at IS NOT NULL, COALESCE(posts.published_at, posts.created_at), posts.id)
+        < ($6::boolean, $5::timestamp, $7::uuid)
+)
+AND (
+    NOT $8::boolean
+    OR NOT EXISTS (
+        SELECT 1 FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+    )
+)
+ORDER BY posts.published_at IS NOT NULL DESC, COALESCE(posts.published_at, posts.created_at) DESC, posts.id DESC
+LIMIT $9
+`
+
+type BrowsePostsParams struct {
+	UserID      uuid.UUID
+	FeedID      uuid.NullUUID
+	Since       sql.NullTime
+	Until       sql.NullTime
+	BeforeTime  sql.NullTime
+	BeforeDated sql.NullBool
+	BeforeID    uuid.NullUUID
+	UnreadOnly  bool
+	MaxPosts    int32
+}
+
+type BrowsePostsRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.NullUUID
+	Content     sql.NullString
+}
+
+func (q *Queries) BrowsePosts(ctx context.Context, arg BrowsePostsParams) ([]BrowsePostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, browsePosts,
+		arg.UserID,
+		arg.FeedID,
+		arg.Since,
+		arg.Until,
+		arg.BeforeTime,
+		arg.BeforeDated,
+		arg.BeforeID,
+		arg.UnreadOnly,
+		arg.MaxPosts,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BrowsePostsRow
+	for rows.Next() {
+		var i BrowsePostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Content,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createPost = `-- name: CreatePost :execrows
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content)
 VALUES (
     $1,
     $2,
@@ -23,8 +114,10 @@ VALUES (
     $5,
     $6,
     $7,
-    $8
+    $8,
+    $9
 )
+ON CONFLICT (url) DO NOTHING
 `
 
 type CreatePostParams struct {
@@ -36,10 +129,11 @@ type CreatePostParams struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.NullUUID
+	Content     sql.NullString
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
-	_, err := q.db.ExecContext(ctx, createPost,
+func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createPost,
 		arg.ID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
@@ -48,42 +142,184 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Content,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id
-FROM posts
-INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
-WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
+const getPostByID = `-- name: GetPostByID :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, content FROM posts WHERE id = $1
+`
+
+type GetPostByIDRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.NullUUID
+	Content     sql.NullString
+}
+
+func (q *Queries) GetPostByID(ctx context.Context, id uuid.UUID) (GetPostByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostByID, id)
+	var i GetPostByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Content,
+	)
+	return i, err
+}
+
+const getPostByURL = `-- name: GetPostByURL :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, content FROM posts WHERE url = $1
+`
+
+type GetPostByURLRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.NullUUID
+	Content     sql.NullString
+}
+
+func (q *Queries) GetPostByURL(ctx context.Context, url string) (GetPostByURLRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostByURL, url)
+	var i GetPostByURLRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Content,
+	)
+	return i, err
+}
+
+const getRecentPostDates = `-- name: GetRecentPostDates :many
+SELECT published_at FROM posts
+WHERE feed_id = $1 AND published_at IS NOT NULL
+ORDER BY published_at DESC
 LIMIT $2
 `
 
-type GetPostsForUserParams struct {
-	UserID uuid.UUID
+type GetRecentPostDatesParams struct {
+	FeedID uuid.NullUUID
 	Limit  int32
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+func (q *Queries) GetRecentPostDates(ctx context.Context, arg GetRecentPostDatesParams) ([]sql.NullTime, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentPostDates, arg.FeedID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []sql.NullTime
 	for rows.Next() {
-		var i Post
+		var published_at sql.NullTime
+		if err := rows.Scan(&published_at); err != nil {
+			return nil, err
+		}
+		items = append(items, published_at)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPosts = `-- name: SearchPosts :many
+SELECT
+    posts.id,
+    posts.title,
+    posts.url,
+    posts.published_at,
+    feeds.name AS feed_name,
+    ts_rank(posts.search_vector, search_query) AS rank,
+    ts_headline(
+        'english',
+        coalesce(NULLIF(posts.description, ''), posts.content, posts.title),
+        search_query,
+        'StartSel=**, StopSel=**, MaxWords=35, MinWords=15, MaxFragments=2'
+    ) AS headline
+FROM posts
+CROSS JOIN websearch_to_tsquery('english', $1) AS search_query
+LEFT JOIN feeds ON posts.feed_id = feeds.id
+WHERE posts.search_vector @@ search_query
+AND (
+    $2::boolean
+    OR EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id
+        AND feed_follows.user_id = $3
+    )
+)
+ORDER BY rank DESC, posts.published_at DESC NULLS LAST
+LIMIT $4
+`
+
+type SearchPostsParams struct {
+	Query      string
+	AllFeeds   bool
+	UserID     uuid.UUID
+	MaxResults int32
+}
+
+type SearchPostsRow struct {
+	ID          uuid.UUID
+	Title       string
+	Url         string
+	PublishedAt sql.NullTime
+	FeedName    sql.NullString
+	Rank        float32
+	Headline    string
+}
+
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts,
+		arg.Query,
+		arg.AllFeeds,
+		arg.UserID,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsRow
+	for rows.Next() {
+		var i SearchPostsRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
 			&i.Title,
 			&i.Url,
-			&i.Description,
 			&i.PublishedAt,
-			&i.FeedID,
+			&i.FeedName,
+			&i.Rank,
+			&i.Headline,
 		); err != nil {
 			return nil, err
 		}
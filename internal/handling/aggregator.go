@@ -0,0 +1,231 @@
+package handling
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wfcornelissen/blogag/internal/config"
+	"github.com/wfcornelissen/blogag/internal/database"
+	"github.com/wfcornelissen/blogag/internal/output"
+	"github.com/wfcornelissen/blogag/internal/rss"
+)
+
+// aggregator fetches stale feeds with a pool of workers. Feeds are claimed
+// in batches with FOR UPDATE SKIP LOCKED, so several agg processes can run
+// against the same database without fetching the same feed twice.
+type aggregator struct {
+	s        *config.State
+	out      output.Format
+	workers  int
+	perHost  int
+	interval time.Duration
+
+	outMu sync.Mutex
+
+	hostMu    sync.Mutex
+	hostSlots map[string]chan struct{}
+
+	fetched  atomic.Int64
+	failed   atomic.Int64
+	newPosts atomic.Int64
+}
+
+func newAggregator(s *config.State, out output.Format, workers, perHost int, interval time.Duration) *aggregator {
+	return &aggregator{
+		s:         s,
+		out:       out,
+		workers:   workers,
+		perHost:   perHost,
+		interval:  interval,
+		hostSlots: make(map[string]chan struct{}),
+	}
+}
+
+// runOnce claims and fetches batches of due feeds until none are left or
+// stop is cancelled. Fetches and inserts run under work, which outlives stop
+// so in-flight feeds can finish during shutdown.
+func (a *aggregator) runOnce(stop, work context.Context) error {
+	jobs := make(chan database.Feed)
+	var wg sync.WaitGroup
+	for i := 0; i < a.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for feed := range jobs {
+				a.fetch(work, feed)
+			}
+		}()
+	}
+
+	var claimErr error
+claim:
+	for stop.Err() == nil {
+		now := time.Now()
+		feeds, err := a.s.Db.ClaimFeedsToFetch(stop,
+			database.ClaimFeedsToFetchParams{
+				Now:          sql.NullTime{Time: now, Valid: true},
+				BatchSize:    int32(a.workers),
+				ClaimedUntil: sql.NullTime{Time: now.Add(claimLease), Valid: true},
+			})
+		if err != nil {
+			if stop.Err() == nil {
+				claimErr = fmt.Errorf("Failed to claim feeds:\n%v\n", err)
+			}
+			break
+		}
+		if len(feeds) == 0 {
+			break
+		}
+		for _, feed := range feeds {
+			select {
+			case jobs <- feed:
+			case <-stop.Done():
+				break claim
+			}
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+	return claimErr
+}
+
+func (a *aggregator) fetch(ctx context.Context, feed database.Feed) {
+	release := a.acquireHost(feed.Url.String)
+	defer release()
+
+	hints := hintsFromRow(feed)
+	failures := int32(0)
+
+	parsed, newPosts, err := scrapeFeed(ctx, a.s, feed)
+	if err != nil {
+		a.report(FetchView{Feed: feed.Name.String, Error: strings.TrimSpace(err.Error())})
+		a.failed.Add(1)
+		a.recordFailure(ctx, feed, err)
+		failures = feed.ConsecutiveFailures + 1
+	} else {
+		a.report(FetchView{Feed: feed.Name.String, NewPosts: newPosts})
+		a.fetched.Add(1)
+		a.newPosts.Add(int64(newPosts))
+
+		if feed.ConsecutiveFailures > 0 {
+			err = a.s.Db.RecordFeedSuccess(ctx, feed.ID)
+			if err != nil {
+				a.logf("Failed to clear error for '%s': %v\n", feed.Name.String, err)
+			}
+		}
+
+		// A 304 leaves parsed nil, the stored hints are still current then
+		if parsed != nil {
+			hints = hintsFromFeed(parsed)
+			err = storeScheduleHints(ctx, a.s.Db, feed.ID, hints)
+			if err != nil {
+				a.logf("Failed to store schedule hints for '%s': %v\n", feed.Name.String, err)
+			}
+		}
+	}
+
+	a.schedule(ctx, feed, hints, failures)
+}
+
+// schedule stores when the feed is due again, replacing the claim lease.
+func (a *aggregator) schedule(ctx context.Context, feed database.Feed, hints scheduleHints, failures int32) {
+	postDates, err := recentPostDates(ctx, a.s.Db, feed.ID)
+	if err != nil {
+		a.logf("Failed to read post dates for '%s': %v\n", feed.Name.String, err)
+	}
+
+	next := nextFetchAt(time.Now(), a.interval, postDates, hints, failures)
+	err = a.s.Db.SetFeedNextFetchAt(ctx, database.SetFeedNextFetchAtParams{
+		NextFetchAt: sql.NullTime{Time: next, Valid: true},
+		ID:          feed.ID,
+	})
+	if err != nil {
+		a.logf("Failed to schedule '%s': %v\n", feed.Name.String, err)
+	}
+}
+
+// recordFailure stores the error on the feed row so a single broken feed
+// never stops the aggregator, and feedstatus can report it.
+func (a *aggregator) recordFailure(ctx context.Context, feed database.Feed, fetchErr error) {
+	status := sql.NullInt32{}
+	var httpErr *rss.HTTPError
+	if errors.As(fetchErr, &httpErr) {
+		status = sql.NullInt32{Int32: int32(httpErr.StatusCode), Valid: true}
+	}
+
+	err := a.s.Db.RecordFeedFailure(ctx,
+		database.RecordFeedFailureParams{
+			LastError:       sql.NullString{String: strings.TrimSpace(fetchErr.Error()), Valid: true},
+			LastErrorStatus: status,
+			LastErrorAt:     sql.NullTime{Time: time.Now(), Valid: true},
+			ID:              feed.ID,
+		})
+	if err != nil {
+		a.logf("Failed to record error for '%s': %v\n", feed.Name.String, err)
+	}
+}
+
+// report prints the outcome of a single fetch. Workers report concurrently,
+// so each fetch is written as one record and lines never interleave.
+func (a *aggregator) report(fetch FetchView) {
+	a.outMu.Lock()
+	defer a.outMu.Unlock()
+	// ndjson streams every fetch, the other machine formats only print the
+	// summary and keep the progress log on stderr
+	format, w := output.Text, os.Stdout
+	if a.out == output.NDJSON {
+		format = output.NDJSON
+	} else if a.out.Machine() {
+		w = os.Stderr
+	}
+	err := output.Render(w, format, []FetchView{fetch})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print fetch result: %v\n", err)
+	}
+}
+
+// logf prints a problem that doesn't stop the aggregator.
+func (a *aggregator) logf(format string, args ...any) {
+	w := os.Stdout
+	if a.out.Machine() {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, format, args...)
+}
+
+func (a *aggregator) printSummary() error {
+	return output.RenderOne(os.Stdout, a.out, AggSummaryView{
+		Fetched:  a.fetched.Load(),
+		Failed:   a.failed.Load(),
+		NewPosts: a.newPosts.Load(),
+	})
+}
+
+// acquireHost blocks until fewer than perHost fetches are running against
+// the host of feedURL, and returns the function releasing the slot.
+func (a *aggregator) acquireHost(feedURL string) func() {
+	host := feedURL
+	if parsed, err := url.Parse(feedURL); err == nil {
+		host = parsed.Host
+	}
+
+	a.hostMu.Lock()
+	slots, ok := a.hostSlots[host]
+	if !ok {
+		slots = make(chan struct{}, a.perHost)
+		a.hostSlots[host] = slots
+	}
+	a.hostMu.Unlock()
+
+	slots <- struct{}{}
+	return func() { <-slots }
+}
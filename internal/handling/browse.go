@@ -0,0 +1,188 @@
+package handling
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/config"
+	"github.com/wfcornelissen/blogag/internal/database"
+)
+
+const defaultBrowseLimit = 2
+
+// browseParams turns the browse flags into query parameters.
+func browseParams(ctx context.Context, s *config.State, cmd Command) (database.BrowsePostsParams, error) {
+	// --unread is the default, --all or --unread=false include read posts
+	params := database.BrowsePostsParams{
+		UnreadOnly: cmd.boolFlag("unread") && !cmd.boolFlag("all"),
+		MaxPosts:   defaultBrowseLimit,
+	}
+
+	if len(cmd.Args) == 1 {
+		limit, err := strconv.Atoi(cmd.Args[0])
+		if err != nil || limit < 1 {
+			return params, usageErrorf(cmd, "Invalid post limit '%s'", cmd.Args[0])
+		}
+		params.MaxPosts = int32(limit)
+	}
+
+	if ref := cmd.stringFlag("feed"); ref != "" {
+		feed, err := findFeed(ctx, s, ref)
+		if err != nil {
+			return params, err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	if ref := cmd.stringFlag("since"); ref != "" {
+		since, err := parseTimeArg(ref)
+		if err != nil {
+			return params, usageErrorf(cmd, "%v", err)
+		}
+		params.Since = sql.NullTime{Time: since, Valid: true}
+	}
+
+	if ref := cmd.stringFlag("until"); ref != "" {
+		until, err := parseTimeArg(ref)
+		if err != nil {
+			return params, usageErrorf(cmd, "%v", err)
+		}
+		params.Until = sql.NullTime{Time: until, Valid: true}
+	}
+
+	if ref := cmd.stringFlag("before"); ref != "" {
+		before, dated, id, err := decodeCursor(ref)
+		if err != nil {
+			return params, usageErrorf(cmd, "%v", err)
+		}
+		setBefore(&params, before, dated, id)
+	}
+
+	return params, nil
+}
+
+// nextPageCommand returns the browse command line that shows the page
+// after last, with the limit and filters of cmd. Ages given to --since and
+// --until are written as the times they resolved to, so the next page
+// covers the same range.
+func nextPageCommand(cmd Command, params database.BrowsePostsParams, last database.BrowsePostsRow) string {
+	args := []string{"gator", "browse"}
+	if len(cmd.Args) == 1 {
+		args = append(args, cmd.Args[0])
+	}
+	if !params.UnreadOnly {
+		args = append(args, "--all")
+	}
+	if ref := cmd.stringFlag("feed"); ref != "" {
+		args = append(args, "--feed", shellQuote(ref))
+	}
+	if params.Since.Valid {
+		args = append(args, "--since", params.Since.Time.Format(time.RFC3339Nano))
+	}
+	if params.Until.Valid {
+		args = append(args, "--until", params.Until.Time.Format(time.RFC3339Nano))
+	}
+	args = append(args, "--before", encodeCursor(last))
+	return strings.Join(args, " ")
+}
+
+// shellQuote quotes value for a POSIX shell when it holds anything but
+// plain word characters.
+func shellQuote(value string) string {
+	plain := strings.Trim(value, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:@%+=,") == ""
+	if plain {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// findFeed looks a feed up by URL, falling back to its name.
+func findFeed(ctx context.Context, s *config.State, ref string) (database.Feed, error) {
+	feed, err := s.Db.GetFeedByURL(ctx, sql.NullString{String: ref, Valid: true})
+	if err == sql.ErrNoRows {
+		feed, err = s.Db.GetFeedByName(ctx, sql.NullString{String: ref, Valid: true})
+	}
+	if err == sql.ErrNoRows {
+		return database.Feed{}, fmt.Errorf("feed '%s' doesnt exist", ref)
+	}
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("Failed to fetch feed:\n%v\n", err)
+	}
+	return feed, nil
+}
+
+// postSortTime is the time browse orders by. Posts with a publication date
+// come first, the ones without follow by when they were stored.
+func postSortTime(post database.BrowsePostsRow) time.Time {
+	if post.PublishedAt.Valid {
+		return post.PublishedAt.Time
+	}
+	return post.CreatedAt
+}
+
+// setBefore makes params continue after the post with the given sort key.
+func setBefore(params *database.BrowsePostsParams, sortTime time.Time, dated bool, id uuid.UUID) {
+	params.BeforeTime = sql.NullTime{Time: sortTime, Valid: true}
+	params.BeforeDated = sql.NullBool{Bool: dated, Valid: true}
+	params.BeforeID = uuid.NullUUID{UUID: id, Valid: true}
+}
+
+// encodeCursor returns an opaque keyset cursor pointing after post.
+func encodeCursor(post database.BrowsePostsRow) string {
+	raw := postSortTime(post).Format(time.RFC3339Nano) + "|" + post.ID.String()
+	if !post.PublishedAt.Valid {
+		raw += "|undated"
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor returns the sort time, whether the post has a publication
+// date and the ID of the post a cursor points after.
+func decodeCursor(cursor string) (time.Time, bool, uuid.UUID, error) {
+	invalid := fmt.Errorf("Invalid cursor '%s'", cursor)
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, false, uuid.UUID{}, invalid
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) < 2 || len(parts) > 3 || len(parts) == 3 && parts[2] != "undated" {
+		return time.Time{}, false, uuid.UUID{}, invalid
+	}
+	t, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, false, uuid.UUID{}, invalid
+	}
+	postID, err := uuid.Parse(parts[1])
+	if err != nil {
+		return time.Time{}, false, uuid.UUID{}, invalid
+	}
+	return t.UTC(), len(parts) == 2, postID, nil
+}
+
+// isInteractive reports whether stdin is a terminal, so prompting makes sense.
+func isInteractive() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func confirm(question string) bool {
+	fmt.Printf("%s [Y/n]: ", question)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "" || answer == "y" || answer == "yes"
+}
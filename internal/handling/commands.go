@@ -0,0 +1,201 @@
+package handling
+
+import "flag"
+
+// Specs of the commands, registered with their handlers in main.
+
+var HelpCommand = CommandSpec{
+	Name:    "help",
+	Summary: "Show the commands, or how to use one of them",
+	Args:    []string{"[command]"},
+	Offline: true,
+}
+
+var ShellCommand = CommandSpec{
+	Name:    "shell",
+	Summary: "Run commands interactively over a single database connection",
+}
+
+var MigrateCommand = CommandSpec{
+	Name:      "migrate",
+	Summary:   "Manage the database schema: up, down, status or version",
+	Args:      []string{"action"},
+	AnySchema: true,
+	Complete:  completeMigrateActions,
+}
+
+var ConfigCommand = CommandSpec{
+	Name:     "config",
+	Summary:  "Show or change settings: list, get <key> or set <key> <value>",
+	Args:     []string{"action", "[key]", "[value]"},
+	Offline:  true,
+	Complete: completeConfig,
+}
+
+var ProfileCommand = CommandSpec{
+	Name:     "profile",
+	Summary:  "Manage profiles: list, use <name>, add <name> [db_url] or remove <name>",
+	Args:     []string{"action", "[name]", "[db_url]"},
+	Offline:  true,
+	Complete: completeProfileArgs,
+}
+
+var LoginCommand = CommandSpec{
+	Name:     "login",
+	Summary:  "Switch to an existing user",
+	Args:     []string{"username"},
+	Complete: completeUsers,
+}
+
+var RegisterCommand = CommandSpec{
+	Name:    "register",
+	Summary: "Create a user and log in as them",
+	Args:    []string{"username"},
+}
+
+var ResetCommand = CommandSpec{
+	Name:    "reset",
+	Summary: "Delete all users, feeds and posts",
+}
+
+var UsersCommand = CommandSpec{
+	Name:    "users",
+	Summary: "List the users",
+}
+
+var AggCommand = CommandSpec{
+	Name:    "agg",
+	Summary: "Fetch due feeds every interval until stopped",
+	Args:    []string{"interval"},
+	Flags: func(fs *flag.FlagSet) {
+		fs.Int("workers", 0, "number of feeds fetched at once (default agg.workers from the config)")
+		fs.Int("per-host", 0, "number of feeds fetched at once from the same host (default agg.per_host from the config)")
+	},
+}
+
+var FeedStatusCommand = CommandSpec{
+	Name:    "feedstatus",
+	Summary: "List the feeds that failed to fetch",
+}
+
+var AddFeedCommand = CommandSpec{
+	Name:    "addfeed",
+	Summary: "Add and follow a feed, or discover it from a website URL",
+	Args:    []string{"[name]", "url"},
+}
+
+var FeedsCommand = CommandSpec{
+	Name:    "feeds",
+	Summary: "List all feeds",
+}
+
+var FollowCommand = CommandSpec{
+	Name:     "follow",
+	Summary:  "Follow a feed by name or URL",
+	Args:     []string{"feed"},
+	Complete: completeFeeds,
+}
+
+var FollowingCommand = CommandSpec{
+	Name:    "following",
+	Summary: "List the feeds you follow",
+}
+
+var UnfollowCommand = CommandSpec{
+	Name:     "unfollow",
+	Summary:  "Unfollow a feed by name or URL",
+	Args:     []string{"feed"},
+	Complete: completeFollowedFeeds,
+}
+
+var BrowseCommand = CommandSpec{
+	Name:    "browse",
+	Summary: "Show posts from the feeds you follow, newest first",
+	Args:    []string{"[limit]"},
+	Flags: func(fs *flag.FlagSet) {
+		fs.Bool("all", false, "include posts already read")
+		fs.Bool("unread", true, "only show unread posts")
+		fs.String("feed", "", "only show posts from the feed with this `name or URL`")
+		fs.String("since", "", "only show posts newer than this `age or date`")
+		fs.String("until", "", "only show posts older than this `age or date`")
+		fs.String("before", "", "continue after the post this `cursor` points at")
+	},
+}
+
+var readFlags = func(fs *flag.FlagSet) {
+	fs.String("feed", "", "mark every post of the feed with this `name or URL`")
+	fs.String("older-than", "", "mark every followed post older than this `age or date`")
+}
+
+var ReadCommand = CommandSpec{
+	Name:    "read",
+	Summary: "Mark a post, a feed, or old posts as read",
+	Args:    []string{"[post]"},
+	Flags:   readFlags,
+}
+
+var UnreadCommand = CommandSpec{
+	Name:    "unread",
+	Summary: "Mark a post, a feed, or old posts as unread",
+	Args:    []string{"[post]"},
+	Flags:   readFlags,
+}
+
+var StarCommand = CommandSpec{
+	Name:    "star",
+	Summary: "Star a post by ID or URL, with an optional note",
+	Args:    []string{"post", "[note...]"},
+}
+
+var UnstarCommand = CommandSpec{
+	Name:    "unstar",
+	Summary: "Remove the star from a post",
+	Args:    []string{"post"},
+}
+
+var StarredCommand = CommandSpec{
+	Name:    "starred",
+	Summary: "List your starred posts",
+}
+
+var SearchCommand = CommandSpec{
+	Name:    "search",
+	Summary: "Search posts; quote -excluded words or put them after --",
+	Args:    []string{"query..."},
+	Flags: func(fs *flag.FlagSet) {
+		fs.Bool("all", false, "search every feed rather than the ones you follow")
+		fs.Int("limit", defaultSearchLimit, "maximum number of results")
+	},
+}
+
+var ImportOPMLCommand = CommandSpec{
+	Name:    "import-opml",
+	Summary: "Add and follow the feeds of an OPML file",
+	Args:    []string{"file"},
+}
+
+var CompletionCommand = CommandSpec{
+	Name:     "completion",
+	Summary:  "Print the completion script for bash, zsh or fish",
+	Args:     []string{"shell"},
+	Offline:  true,
+	Complete: completeShells,
+}
+
+// CompleteCommand is called by the completion scripts with the words typed
+// so far, the last one being the word to complete.
+var CompleteCommand = CommandSpec{
+	Name:       "__complete",
+	Summary:    "Print completions for a partial command line",
+	Args:       []string{"[words...]"},
+	Hidden:     true,
+	RawArgs:    true,
+	OptionalDB: true,
+	AnySchema:  true,
+}
+
+var ExportOPMLCommand = CommandSpec{
+	Name:    "export-opml",
+	Summary: "Write the feeds you follow as OPML, to stdout by default",
+	Args:    []string{"[file]"},
+}
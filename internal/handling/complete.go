@@ -0,0 +1,206 @@
+package handling
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/wfcornelissen/blogag/internal/config"
+)
+
+// HandlerComplete prints one completion per line for the words typed so
+// far. Errors are swallowed, a failing lookup just completes nothing.
+func (c *Commands) HandlerComplete(ctx context.Context, s *config.State, cmd Command) error {
+	for _, candidate := range c.Complete(ctx, s, cmd.Args) {
+		fmt.Fprintln(os.Stdout, candidate)
+	}
+	return nil
+}
+
+// Complete returns the completions of the last word, given the words before
+// it. The first word is the command name.
+func (c *Commands) Complete(ctx context.Context, s *config.State, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	if len(words) == 1 {
+		return withPrefix(c.Names(), current)
+	}
+
+	spec, exists := c.Commands[words[0]]
+	if !exists || spec.RawArgs {
+		return nil
+	}
+
+	// Walk the finished words to find which positional argument, or which
+	// flag value, is being completed
+	fs := spec.flagSet()
+	index, flagName, terminated := 0, "", false
+	for _, word := range words[1 : len(words)-1] {
+		switch {
+		case flagName != "":
+			flagName = ""
+		case word == "--":
+			terminated = true
+		case !terminated && strings.HasPrefix(word, "-"):
+			name := strings.TrimLeft(word, "-")
+			if f := fs.Lookup(name); f != nil && !isBoolFlag(f) {
+				flagName = name
+			}
+		default:
+			index++
+		}
+	}
+
+	var candidates []string
+	var err error
+	switch {
+	case flagName == "output" || flagName == "o":
+		candidates = []string{"text", "table", "json", "ndjson", "csv"}
+	case flagName == "feed":
+		candidates, err = completeFeeds(ctx, s, 0)
+	case flagName == "profile":
+		candidates, err = completeProfiles(ctx, s, 0)
+	case flagName != "":
+		// No suggestions for free form values
+	case !terminated && strings.HasPrefix(current, "-"):
+		candidates = flagNames(fs)
+	case spec.Name == HelpCommand.Name && index == 0:
+		candidates = c.Names()
+	case spec.Complete != nil:
+		candidates, err = spec.Complete(ctx, s, index)
+	}
+	if err != nil {
+		return nil
+	}
+	return withPrefix(candidates, current)
+}
+
+func completeUsers(ctx context.Context, s *config.State, index int) ([]string, error) {
+	if index > 0 || s.Db == nil {
+		return nil, nil
+	}
+	users, err := s.Db.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+	return names, nil
+}
+
+// completeFeeds offers both the name and the URL of every feed, either is
+// accepted where a feed is expected.
+func completeFeeds(ctx context.Context, s *config.State, index int) ([]string, error) {
+	if index > 0 || s.Db == nil {
+		return nil, nil
+	}
+	feeds, err := s.Db.GetAllFeeds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []string{}
+	for _, feed := range feeds {
+		refs = append(refs, feed.Name.String, feed.Url.String)
+	}
+	return refs, nil
+}
+
+func completeFollowedFeeds(ctx context.Context, s *config.State, index int) ([]string, error) {
+	if index > 0 || s.Db == nil {
+		return nil, nil
+	}
+	user, err := s.Db.GetUser(ctx, s.State.CurrentUserName)
+	if err != nil {
+		return nil, err
+	}
+	following, err := s.Db.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []string{}
+	for _, follow := range following {
+		refs = append(refs, follow.FeedName.String, follow.FeedUrl.String)
+	}
+	return refs, nil
+}
+
+func completeMigrateActions(ctx context.Context, s *config.State, index int) ([]string, error) {
+	if index > 0 {
+		return nil, nil
+	}
+	return []string{"up", "down", "status", "version"}, nil
+}
+
+func completeConfig(ctx context.Context, s *config.State, index int) ([]string, error) {
+	switch index {
+	case 0:
+		return configActions, nil
+	case 1:
+		return config.Keys(), nil
+	}
+	return nil, nil
+}
+
+func completeProfiles(ctx context.Context, s *config.State, index int) ([]string, error) {
+	if index > 0 || s.State == nil {
+		return nil, nil
+	}
+	return s.State.ProfileNames(), nil
+}
+
+// completeProfileArgs completes the action, then the profile for use and
+// remove.
+func completeProfileArgs(ctx context.Context, s *config.State, index int) ([]string, error) {
+	if index == 0 {
+		return profileActions, nil
+	}
+	return completeProfiles(ctx, s, index-1)
+}
+
+func completeShells(ctx context.Context, s *config.State, index int) ([]string, error) {
+	if index > 0 {
+		return nil, nil
+	}
+	return []string{"bash", "zsh", "fish"}, nil
+}
+
+// flagNames lists the long form of every flag, skipping shorthands.
+func flagNames(fs *flag.FlagSet) []string {
+	names := []string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if len(f.Name) > 1 {
+			names = append(names, "--"+f.Name)
+		}
+	})
+	return names
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// withPrefix returns the sorted, distinct candidates starting with prefix.
+func withPrefix(candidates []string, prefix string) []string {
+	seen := map[string]bool{}
+	matches := []string{}
+	for _, candidate := range candidates {
+		if candidate == "" || seen[candidate] || !strings.HasPrefix(candidate, prefix) {
+			continue
+		}
+		seen[candidate] = true
+		matches = append(matches, candidate)
+	}
+	sort.Strings(matches)
+	return matches
+}
@@ -0,0 +1,74 @@
+package handling
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wfcornelissen/blogag/internal/config"
+)
+
+// The scripts hand the typed words to the hidden __complete command, so
+// completions always follow the registered commands and the database.
+
+const bashCompletion = `# bash completion for gator
+# Load with: source <(gator completion bash)
+_gator() {
+    local cur words cword
+    if declare -F _get_comp_words_by_ref >/dev/null; then
+        # Keep URLs whole, bash splits words on : otherwise
+        _get_comp_words_by_ref -n =: cur words cword
+    else
+        words=("${COMP_WORDS[@]}")
+        cword=$COMP_CWORD
+        cur=${COMP_WORDS[COMP_CWORD]}
+    fi
+
+    local IFS=$'\n'
+    COMPREPLY=($(gator __complete "${words[@]:1:cword}" 2>/dev/null))
+    if declare -F __ltrim_colon_completions >/dev/null; then
+        __ltrim_colon_completions "$cur"
+    fi
+}
+complete -o default -F _gator gator
+`
+
+const zshCompletion = `#compdef gator
+# zsh completion for gator
+# Load with: source <(gator completion zsh)
+_gator() {
+    local out
+    local -a candidates
+    out=$(gator __complete "${(@)words[2,CURRENT]}" 2>/dev/null)
+    [[ -n $out ]] && candidates=("${(@f)out}")
+    compadd -a candidates
+}
+
+if [[ "$funcstack[1]" = "_gator" ]]; then
+    _gator "$@"
+else
+    compdef _gator gator
+fi
+`
+
+const fishCompletion = `# fish completion for gator
+# Load with: gator completion fish | source
+function __gator_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    gator __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c gator -f -a '(__gator_complete)'
+`
+
+func HandlerCompletion(ctx context.Context, s *config.State, cmd Command) error {
+	switch cmd.Args[0] {
+	case "bash":
+		fmt.Print(bashCompletion)
+	case "zsh":
+		fmt.Print(zshCompletion)
+	case "fish":
+		fmt.Print(fishCompletion)
+	default:
+		return usageErrorf(cmd, "Unknown shell '%s'. Expected bash, zsh or fish", cmd.Args[0])
+	}
+	return nil
+}
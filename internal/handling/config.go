@@ -0,0 +1,75 @@
+package handling
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wfcornelissen/blogag/internal/config"
+)
+
+// HandlerConfig reads and changes the config file.
+func HandlerConfig(ctx context.Context, s *config.State, cmd Command) error {
+	cfg := s.State
+	switch cmd.Args[0] {
+	case "list":
+		if len(cmd.Args) > 1 {
+			return usageErrorf(cmd, "Too many arguments. Usage: gator config list")
+		}
+		views := []ConfigView{}
+		for _, key := range config.Keys() {
+			value, source, err := cfg.Get(key)
+			if err != nil {
+				return err
+			}
+			if key == "db_url" {
+				value = redactURL(value)
+			}
+			views = append(views, ConfigView{Key: key, Value: value, Source: source})
+		}
+		cmd.notef("Config file: %v\n", cfg.Path())
+		return render(cmd, views)
+
+	case "get":
+		if len(cmd.Args) != 2 {
+			return usageErrorf(cmd, "Usage: gator config get <key>")
+		}
+		value, _, err := cfg.Get(cmd.Args[1])
+		if err != nil {
+			return usageErrorf(cmd, "%v", err)
+		}
+		fmt.Println(value)
+
+	case "set":
+		if len(cmd.Args) != 3 {
+			return usageErrorf(cmd, "Usage: gator config set <key> <value>")
+		}
+		key, value := cmd.Args[1], cmd.Args[2]
+		if _, _, err := cfg.Get(key); err != nil {
+			return usageErrorf(cmd, "%v", err)
+		}
+		err := cfg.Set(key, value)
+		if err != nil {
+			return err
+		}
+		cmd.notef("Set %v in %v\n", key, cfg.Path())
+
+		// The file changed, but the environment still wins
+		if _, source, _ := cfg.Get(key); source != config.SourceEnv {
+			return nil
+		}
+		for _, name := range config.EnvNames(key) {
+			if os.Getenv(name) != "" {
+				cmd.notef("Note: %v is set and overrides %v\n", name, key)
+				break
+			}
+		}
+
+	default:
+		return usageErrorf(cmd, "Unknown action '%s'. Expected %v", cmd.Args[0], strings.Join(configActions, ", "))
+	}
+	return nil
+}
+
+var configActions = []string{"list", "get", "set"}
@@ -0,0 +1,55 @@
+package handling
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/wfcornelissen/blogag/internal/rss"
+)
+
+// resolveFeedURL turns whatever the user pasted, a feed or a website, into a
+// feed, asking the user to choose when the site offers several feeds. The
+// link holds the feed as Discover downloaded it. Messages and the menu go
+// through cmd.notef so they never end up in machine readable output.
+func resolveFeedURL(ctx context.Context, cmd Command, pageURL string) (rss.FeedLink, error) {
+	links, err := rss.Discover(ctx, pageURL)
+	if err != nil {
+		return rss.FeedLink{}, fmt.Errorf("Couldn't find a feed at '%s':\n%v\n", pageURL, err)
+	}
+
+	if len(links) == 1 {
+		if links[0].URL != pageURL {
+			cmd.notef("Found feed %v\n", links[0].URL)
+		}
+		return links[0], nil
+	}
+
+	return chooseFeed(cmd, links)
+}
+
+func chooseFeed(cmd Command, links []rss.FeedLink) (rss.FeedLink, error) {
+	cmd.notef("Found several feeds:\n")
+	for i, link := range links {
+		if link.Title != "" {
+			cmd.notef(" %v) %v (%v)\n", i+1, link.Title, link.URL)
+			continue
+		}
+		cmd.notef(" %v) %v\n", i+1, link.URL)
+	}
+	cmd.notef("Choose a feed [1-%v]: ", len(links))
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return rss.FeedLink{}, fmt.Errorf("No feed chosen")
+	}
+
+	choice, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || choice < 1 || choice > len(links) {
+		return rss.FeedLink{}, fmt.Errorf("Invalid choice '%s'", strings.TrimSpace(line))
+	}
+	return links[choice-1], nil
+}
@@ -4,21 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"strconv"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/wfcornelissen/blogag/internal/config"
 	"github.com/wfcornelissen/blogag/internal/database"
+	"github.com/wfcornelissen/blogag/internal/output"
 	"github.com/wfcornelissen/blogag/internal/rss"
 )
 
-func HandlerLogin(s *config.State, cmd Command) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("No arguements passed. Expected username")
-	}
+func HandlerLogin(ctx context.Context, s *config.State, cmd Command) error {
 	// Check if user exists
-	_, err := s.Db.GetUser(context.Background(), cmd.Args[0])
+	_, err := s.Db.GetUser(ctx, cmd.Args[0])
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return fmt.Errorf("user '%s' doesnt exist", cmd.Args[0])
@@ -32,18 +31,14 @@ func HandlerLogin(s *config.State, cmd Command) error {
 		return fmt.Errorf("Failed to set user: %v\n", err)
 	}
 
-	fmt.Printf("Set user %v successfully!\n", cmd.Args[0])
+	cmd.notef("Set user %v successfully!\n", cmd.Args[0])
 
 	return nil
 }
 
-func HandlerRegister(s *config.State, cmd Command) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("No arguements passed. Expected username")
-	}
-
+func HandlerRegister(ctx context.Context, s *config.State, cmd Command) error {
 	// Check if user already exists
-	_, err := s.Db.GetUser(context.Background(), cmd.Args[0])
+	_, err := s.Db.GetUser(ctx, cmd.Args[0])
 	if err == nil {
 		return fmt.Errorf("user '%s' already exists", cmd.Args[0])
 	}
@@ -59,129 +54,261 @@ func HandlerRegister(s *config.State, cmd Command) error {
 		UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
 		Name:      cmd.Args[0],
 	}
-	user, err := s.Db.CreateUser(context.Background(), userParams)
+	user, err := s.Db.CreateUser(ctx, userParams)
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 
 	s.State.SetUser(cmd.Args[0])
 
-	fmt.Println("User was created!")
-	fmt.Println(user)
+	cmd.notef("User %v was created!\n", user.Name)
 
 	return nil
 }
 
-func HandlerReset(s *config.State, cmd Command) error {
-	err := s.Db.ResetDatabase(context.Background())
+func HandlerReset(ctx context.Context, s *config.State, cmd Command) error {
+	err := s.Db.ResetDatabase(ctx)
 	if err != nil {
 		return fmt.Errorf("Error resetting database:\n%v", err)
 	}
 	return nil
 }
 
-func HandlerUsers(s *config.State, cmd Command) error {
-	users, err := s.Db.GetUsers(context.Background())
+func HandlerUsers(ctx context.Context, s *config.State, cmd Command) error {
+	users, err := s.Db.GetUsers(ctx)
 	if err != nil {
 		return fmt.Errorf("Couldn't retrieve users from database:\n%v\n", err)
 	}
 
+	views := []UserView{}
 	for _, user := range users {
-		if user.Name == s.State.CurrentUserName {
-			fmt.Printf(" * %v (current)\n", user.Name)
-			continue
-		}
-		fmt.Printf(" * %v\n", user.Name)
+		views = append(views, UserView{
+			Name:    user.Name,
+			Current: user.Name == s.State.CurrentUserName,
+		})
 	}
-	return nil
+	return render(cmd, views)
 }
 
-func HandlerAgg(s *config.State, cmd Command) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("No arguements passed. Expected username")
+func HandlerAgg(ctx context.Context, s *config.State, cmd Command) error {
+	duration, err := time.ParseDuration(cmd.Args[0])
+	if err != nil || duration <= 0 {
+		return usageErrorf(cmd, "Invalid interval '%s'", cmd.Args[0])
 	}
 
-	duration, err := time.ParseDuration(cmd.Args[0])
-	if err != nil {
-		return fmt.Errorf("Failed to parse duration:/n%v/n", err)
+	workers := s.State.Agg.Workers
+	if cmd.isSet("workers") {
+		workers = cmd.intFlag("workers")
+	}
+	if workers < 1 {
+		return usageErrorf(cmd, "Invalid worker count %v", workers)
 	}
 
+	perHost := s.State.Agg.PerHost
+	if cmd.isSet("per-host") {
+		perHost = cmd.intFlag("per-host")
+	}
+	if perHost < 1 {
+		return usageErrorf(cmd, "Invalid per-host limit %v", perHost)
+	}
+
+	agg := newAggregator(s, cmd.Output, workers, perHost, duration)
+
+	// In-flight fetches get their own context so they can finish after ctx
+	// is cancelled, but no longer than agg.shutdown_timeout
+	shutdownTimeout := s.State.Agg.ShutdownTimeout.Duration
+	work, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+	go func() {
+		<-ctx.Done()
+		cmd.notef("Shutting down, waiting up to %v for in-flight fetches\n", shutdownTimeout)
+		select {
+		case <-time.After(shutdownTimeout):
+			cancelWork()
+		case <-work.Done():
+		}
+	}()
+
+	cmd.notef("Collecting feeds every %v with %v workers\n", duration, workers)
 	ticker := time.NewTicker(duration)
-	for ; ; <-ticker.C {
-		fmt.Printf("Collecting feeds every %v", duration)
-		err := scrapeFeeds(s)
+	defer ticker.Stop()
+	for {
+		err = agg.runOnce(ctx, work)
 		if err != nil {
-			break
+			// Keep going, the database may be back by the next tick
+			cmd.notef("%v\n", err)
 		}
-	}
 
-	return err
+		select {
+		case <-ctx.Done():
+			return agg.printSummary()
+		case <-ticker.C:
+		}
+	}
 }
 
-func HandlerAddFeed(s *config.State, cmd Command, user database.User) error {
-	if len(cmd.Args) < 2 {
-		return fmt.Errorf("Too few arguements passed. Expected feed name and URL.")
+func HandlerFeedStatus(ctx context.Context, s *config.State, cmd Command) error {
+	feeds, err := s.Db.GetFailingFeeds(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to fetch feed status from db:\n%v\n", err)
 	}
 
-	feed := database.CreateFeedParams{
-		ID:        uuid.New(),
-		CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
-		UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
-		Name:      sql.NullString{String: cmd.Args[0], Valid: true},
-		Url:       sql.NullString{String: cmd.Args[1], Valid: true},
-		UserID:    user.ID,
+	if len(feeds) == 0 {
+		cmd.notef("All feeds are healthy\n")
+	}
+
+	views := []FeedStatusView{}
+	for _, feed := range feeds {
+		views = append(views, newFeedStatusView(feed))
 	}
-	resFeed, err := s.Db.CreateFeed(context.Background(), feed)
+	return render(cmd, views)
+}
+
+func HandlerAddFeed(ctx context.Context, s *config.State, cmd Command, user database.User) error {
+	// The name is optional and defaults to the channel title
+	name, pageURL := "", cmd.Args[0]
+	if len(cmd.Args) >= 2 {
+		name, pageURL = cmd.Args[0], cmd.Args[1]
+	}
+
+	link, err := resolveFeedURL(ctx, cmd, pageURL)
 	if err != nil {
-		return fmt.Errorf("Error uploading feed to db:\n%v\n", err)
+		return err
 	}
 
-	fmt.Println(resFeed)
+	// Discover already downloaded the feed to check it
+	feedURL, parsed, cache := link.URL, link.Feed, link.Cache
+	if parsed == nil {
+		parsed, cache, err = rss.FetchFeed(ctx, feedURL, rss.CacheHeaders{})
+		if err != nil {
+			return fmt.Errorf("Couldn't fetch feed '%s':\n%v\n", feedURL, err)
+		}
+	}
 
-	newFollow := database.CreateFeedFollowParams{
-		ID:        uuid.New(),
-		CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
-		UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
-		UserID:    user.ID,
-		FeedID:    feed.ID,
+	named := name != ""
+	if !named {
+		name = strings.TrimSpace(parsed.Channel.Title)
 	}
+	if name == "" {
+		return fmt.Errorf("Feed '%s' has no title. Expected feed name and URL.", feedURL)
+	}
+
+	// Feed names are unique, check before the insert fails on it
+	_, err = s.Db.GetFeedByName(ctx, sql.NullString{String: name, Valid: true})
+	if err == nil {
+		if named {
+			return fmt.Errorf("A feed named '%s' already exists.", name)
+		}
+		return fmt.Errorf("A feed named '%s' already exists. Name this one with 'gator addfeed <name> %s'.", name, feedURL)
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("Failed to check feed name:\n%v\n", err)
+	}
+
+	feed := database.CreateFeedParams{
+		ID:          uuid.New(),
+		CreatedAt:   sql.NullTime{Time: time.Now(), Valid: true},
+		UpdatedAt:   sql.NullTime{Time: time.Now(), Valid: true},
+		Name:        sql.NullString{String: name, Valid: true},
+		Url:         sql.NullString{String: feedURL, Valid: true},
+		UserID:      user.ID,
+		Description: nullString(parsed.Channel.Description),
+		SiteUrl:     nullString(parsed.Channel.Link),
+		IconUrl:     nullString(parsed.Channel.Image.URL),
+	}
+
+	// The feed, its follow and its posts are stored together, a failure
+	// leaves nothing behind to block adding the feed again
+	var resFeed database.Feed
+	newPosts := 0
+	err = s.InTx(ctx, func(db database.Querier) error {
+		resFeed, err = db.CreateFeed(ctx, feed)
+		if err != nil {
+			return fmt.Errorf("Error uploading feed to db:\n%v\n", err)
+		}
+
+		newFollow := database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+		}
+		_, err = db.CreateFeedFollow(ctx, newFollow)
+		if err != nil {
+			return fmt.Errorf("Failed to create feed follow:\n%v\n", err)
+		}
+
+		// The feed was just fetched, store what agg would have stored
+		err = db.MarkFeedFetched(ctx, database.MarkFeedFetchedParams{
+			LastFetchedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			Url:           resFeed.Url,
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to mark feed as fetched:\n%v\n", err)
+		}
+		err = storeCacheHeaders(ctx, db, resFeed.ID, cache)
+		if err != nil {
+			return err
+		}
+		hints := hintsFromFeed(parsed)
+		err = storeScheduleHints(ctx, db, resFeed.ID, hints)
+		if err != nil {
+			return fmt.Errorf("Failed to store schedule hints:\n%v\n", err)
+		}
 
-	_, err = s.Db.CreateFeedFollow(context.Background(), newFollow)
+		newPosts, err = storePosts(ctx, db, resFeed.ID, parsed.Channel.Item)
+		if err != nil {
+			return err
+		}
+
+		postDates, err := recentPostDates(ctx, db, resFeed.ID)
+		if err != nil {
+			return fmt.Errorf("Failed to read post dates:\n%v\n", err)
+		}
+		err = db.SetFeedNextFetchAt(ctx, database.SetFeedNextFetchAtParams{
+			NextFetchAt: sql.NullTime{Time: nextFetchAt(time.Now(), addFeedInterval, postDates, hints, 0), Valid: true},
+			ID:          resFeed.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to schedule feed:\n%v\n", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("Failed to create feed follow: /n%v/n", err)
+		return err
 	}
 
+	cmd.notef("Added feed '%v' (%v)\n", resFeed.Name.String, resFeed.Url.String)
+	cmd.notef("Stored %v posts\n", newPosts)
 	return nil
 }
 
-func HandlerFeeds(s *config.State, cmd Command) error {
-	feeds, err := s.Db.GetAllFeeds(context.Background())
+func HandlerFeeds(ctx context.Context, s *config.State, cmd Command) error {
+	feeds, err := s.Db.GetAllFeeds(ctx)
 	if err != nil {
 		return fmt.Errorf("Failed to fetch feeds from db:\n%v\n", err)
 	}
 
+	views := []FeedView{}
 	for _, feed := range feeds {
-		userName, err := s.Db.GetUserByID(context.Background(), feed.UserID)
+		userName, err := s.Db.GetUserByID(ctx, feed.UserID)
 		if err != nil {
-			return fmt.Errorf("Failed to fetch username: \n%v\n", err)
+			return fmt.Errorf("Failed to fetch username:\n%v\n", err)
 		}
-		fmt.Printf("Name:	%v\n", feed.Name)
-		fmt.Printf("URL:	%v\n", feed.Url)
-		fmt.Printf("Name:	%v\n", userName)
-		fmt.Println("--- END OF FEEDS ---")
+		views = append(views, FeedView{
+			Name: feed.Name.String,
+			URL:  feed.Url.String,
+			User: userName,
+		})
 	}
-	return nil
+	return render(cmd, views)
 }
 
-func HandlerFollow(s *config.State, cmd Command, user database.User) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("No arguements passed. Expected username")
-	}
-
-	url := cmd.Args[0]
-	feed, err := s.Db.GetFeedByURL(context.Background(), sql.NullString{String: url, Valid: true})
+func HandlerFollow(ctx context.Context, s *config.State, cmd Command, user database.User) error {
+	feed, err := findFeed(ctx, s, cmd.Args[0])
 	if err != nil {
-		return fmt.Errorf("Failed to retrieve feed id: /n%v/n", err)
+		return err
 	}
 
 	newFollow := database.CreateFeedFollowParams{
@@ -192,37 +319,34 @@ func HandlerFollow(s *config.State, cmd Command, user database.User) error {
 		FeedID:    feed.ID,
 	}
 
-	feedFollow, err := s.Db.CreateFeedFollow(context.Background(), newFollow)
+	feedFollow, err := s.Db.CreateFeedFollow(ctx, newFollow)
 	if err != nil {
-		return fmt.Errorf("Failed to create feed follow: /n%v/n", err)
+		return fmt.Errorf("Failed to create feed follow:\n%v\n", err)
 	}
 
-	fmt.Printf("Feed name: %v/nUser name: %v/n", feedFollow.FeedName, user.Name)
+	cmd.notef("Feed name: %v\nUser name: %v\n", feedFollow.FeedName.String, user.Name)
 
 	return nil
 }
 
-func HandlerFollowing(s *config.State, cmd Command, user database.User) error {
+func HandlerFollowing(ctx context.Context, s *config.State, cmd Command, user database.User) error {
 
-	following, err := s.Db.GetFeedFollowsForUser(context.Background(), user.ID)
+	following, err := s.Db.GetFeedFollowsForUser(ctx, user.ID)
 	if err != nil {
-		return fmt.Errorf("Failed to retrieve follows for user id: /n%v/n", err)
+		return fmt.Errorf("Failed to retrieve follows for user id:\n%v\n", err)
 	}
 
+	views := []FollowView{}
 	for _, feed := range following {
-		fmt.Printf("Feed name: %v/n", feed.FeedName)
+		views = append(views, newFollowView(feed))
 	}
-	return nil
+	return render(cmd, views)
 }
 
-func HandlerUnfollow(s *config.State, cmd Command, user database.User) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("No arguements passed. Expected username")
-	}
-
-	feed, err := s.Db.GetFeedByURL(context.Background(), sql.NullString{String: cmd.Args[0], Valid: true})
+func HandlerUnfollow(ctx context.Context, s *config.State, cmd Command, user database.User) error {
+	feed, err := findFeed(ctx, s, cmd.Args[0])
 	if err != nil {
-		return fmt.Errorf("Couldnt get feed ID:/n%v/n", err)
+		return err
 	}
 
 	req := database.DeleteFeedFollowParams{
@@ -230,100 +354,130 @@ func HandlerUnfollow(s *config.State, cmd Command, user database.User) error {
 		FeedID: feed.ID,
 	}
 
-	err = s.Db.DeleteFeedFollow(context.Background(), req)
+	err = s.Db.DeleteFeedFollow(ctx, req)
 	if err != nil {
-		return fmt.Errorf("Couldnt delete feed follow:/n%v/n", err)
+		return fmt.Errorf("Couldnt delete feed follow:\n%v\n", err)
 	}
 
 	return nil
 }
 
-func HandlerBrowse(s *config.State, cmd Command, user database.User) error {
-	postLimit := 2
-	if len(cmd.Args) >= 1 {
-		command, err := strconv.Atoi(cmd.Args[0])
+func HandlerBrowse(ctx context.Context, s *config.State, cmd Command, user database.User) error {
+	params, err := browseParams(ctx, s, cmd)
+	if err != nil {
+		return err
+	}
+	params.UserID = user.ID
+
+	for {
+		posts, err := s.Db.BrowsePosts(ctx, params)
 		if err != nil {
-			return fmt.Errorf("Failed to convert command to int:\n%v\n", err)
+			return fmt.Errorf("Failed to fetch posts:\n%v\n", err)
 		}
-		postLimit = command
-	}
-	params := database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  int32(postLimit),
-	}
 
-	posts, err := s.Db.GetPostsForUser(context.Background(), params)
-	if err != nil {
-		return fmt.Errorf("Failed to fetch posts:/n%v/v", err)
-	}
+		views := []PostView{}
+		for _, post := range posts {
+			views = append(views, newPostView(post))
+		}
+		err = render(cmd, views)
+		if err != nil {
+			return err
+		}
 
-	for _, post := range posts {
-		fmt.Println("════════════════════════════════════════════════════════════")
-		fmt.Printf("📰 %s\n", post.Title)
-		fmt.Printf("🔗 %s\n", post.Url)
-		if post.Description.Valid && post.Description.String != "" {
-			fmt.Printf("📝 %s\n", post.Description.String)
+		// A short page means there is nothing left to show
+		if len(posts) < int(params.MaxPosts) {
+			return nil
 		}
-		if post.PublishedAt.Valid {
-			fmt.Printf("📅 %s\n", post.PublishedAt.Time.Format("Mon, 02 Jan 2006 15:04"))
+
+		// Only prompt for people, scripts page with the cursor of the last post
+		last := posts[len(posts)-1]
+		if cmd.Output != output.Text || !isInteractive() || !confirm("Show next page?") {
+			cmd.notef("Next page: %s\n", nextPageCommand(cmd, params, last))
+			return nil
 		}
+		setBefore(&params, postSortTime(last), last.PublishedAt.Valid, last.ID)
 	}
-	fmt.Println("════════════════════════════════════════════════════════════")
-
-	return nil
 }
 
-func scrapeFeeds(s *config.State) error {
-	feedToFetch, err := s.Db.GetNextFeedToFetch(context.Background())
+// scrapeFeed fetches a single, already claimed, feed and stores its posts.
+// It returns the parsed feed, nil when it was not modified, and the number
+// of new posts.
+func scrapeFeed(ctx context.Context, s *config.State, feedToFetch database.Feed) (*rss.RSSFeed, int, error) {
+	cache := rss.CacheHeaders{
+		ETag:         feedToFetch.Etag.String,
+		LastModified: feedToFetch.LastModified.String,
+	}
+	feed, newCache, err := rss.FetchFeed(ctx, feedToFetch.Url.String, cache)
+	if err == rss.ErrNotModified {
+		return nil, 0, nil
+	}
 	if err != nil {
-		return fmt.Errorf("Failed to fetch next feed:/n%v/n", err)
+		return nil, 0, fmt.Errorf("Failed to fetch feed:\n%v\n", err)
 	}
 
-	err = s.Db.MarkFeedFetched(context.Background(),
-		database.MarkFeedFetchedParams{
-			LastFetchedAt: sql.NullTime{
-				Time:  time.Now(),
-				Valid: true},
-			Url: feedToFetch.Url})
+	// The validators are only saved with the posts, otherwise a failed
+	// insert would get a 304 next time and the posts would never be retried
+	newPosts := 0
+	err = s.InTx(ctx, func(db database.Querier) error {
+		newPosts, err = storePosts(ctx, db, feedToFetch.ID, feed.Channel.Item)
+		if err != nil {
+			return err
+		}
+		return storeCacheHeaders(ctx, db, feedToFetch.ID, newCache)
+	})
 	if err != nil {
-		return fmt.Errorf("Failed to mark feed as fetched:/n%v/n", err)
+		return nil, 0, err
 	}
+	return feed, newPosts, nil
+}
 
-	feed, err := rss.FetchFeed(context.Background(), feedToFetch.Url.String)
+func storeCacheHeaders(ctx context.Context, db database.Querier, feedID uuid.UUID, cache rss.CacheHeaders) error {
+	err := db.UpdateFeedCacheHeaders(ctx,
+		database.UpdateFeedCacheHeadersParams{
+			Etag:         nullString(cache.ETag),
+			LastModified: nullString(cache.LastModified),
+			ID:           feedID})
 	if err != nil {
-		return fmt.Errorf("Failed to mark feed as fetched:/n%v/n", err)
-	}
-
-	feed.Display()
-	if len(feed.Channel.Item) > 0 {
-		for _, item := range feed.Channel.Item {
-			pubAt, err := time.Parse(time.RFC1123Z, item.PubDate)
-			if err != nil {
-				// Try RFC1123 as fallback
-				pubAt, err = time.Parse(time.RFC1123, item.PubDate)
-				if err != nil {
-					fmt.Printf("Warning: couldn't parse date '%s': %v\n", item.PubDate, err)
-					pubAt = time.Now() // Use current time as fallback
-				}
-			}
-			post := database.CreatePostParams{
-				ID:          uuid.New(),
-				CreatedAt:   time.Now(),
-				UpdatedAt:   time.Now(),
-				Title:       item.Title,
-				Url:         item.Link,
-				Description: sql.NullString{String: item.Description, Valid: true},
-				PublishedAt: sql.NullTime{Time: pubAt, Valid: true},
-				FeedID:      uuid.NullUUID{UUID: feedToFetch.ID, Valid: true},
-			}
-
-			err = s.Db.CreatePost(context.Background(), post)
-			if err != nil {
-				// Skip duplicates, continue with other posts
-				fmt.Printf("Skipping post '%s': %v\n", item.Title, err)
-				continue
-			}
-		}
+		return fmt.Errorf("Failed to store feed cache headers:\n%v\n", err)
 	}
 	return nil
 }
+
+// storePosts inserts the feed items as posts, skipping the ones already
+// stored, and returns the number of new posts. Times are stored in UTC, the
+// columns have no time zone and would otherwise keep the feed's local time.
+func storePosts(ctx context.Context, db database.Querier, feedID uuid.UUID, items []rss.RSSItem) (int, error) {
+	newPosts := 0
+	for _, item := range items {
+		now := time.Now().UTC()
+		pubAt, err := rss.ParseDate(item.PubDate)
+		if err != nil {
+			// Leave published_at NULL rather than inventing a date. The
+			// warning goes to stderr, stdout may hold json or ndjson.
+			fmt.Fprintf(os.Stderr, "Warning: couldn't parse date '%s': %v\n", item.PubDate, err)
+		}
+		post := database.CreatePostParams{
+			ID:          uuid.New(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Title:       item.Title,
+			Url:         item.Link,
+			Description: sql.NullString{String: item.Description, Valid: true},
+			PublishedAt: sql.NullTime{Time: pubAt.UTC(), Valid: err == nil},
+			FeedID:      uuid.NullUUID{UUID: feedID, Valid: true},
+			Content:     nullString(item.Content),
+		}
+
+		// A post already stored under the URL inserts no row
+		inserted, err := db.CreatePost(ctx, post)
+		if err != nil {
+			return newPosts, fmt.Errorf("Failed to store post '%s':\n%v\n", item.Link, err)
+		}
+		newPosts += int(inserted)
+	}
+	return newPosts, nil
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -1,29 +1,386 @@
 package handling
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/wfcornelissen/blogag/internal/config"
+	"github.com/wfcornelissen/blogag/internal/output"
+)
+
+// Exit codes, usage errors follow the flag package convention
+const (
+	ExitOK      = 0
+	ExitFailure = 1
+	ExitUsage   = 2
 )
 
 type Command struct {
-	Name string
+	Name   string
+	Args   []string
+	Output output.Format
+	// Config is the --config file override, empty for the default path
+	Config string
+	// Profile is the --profile override, empty for the configured one
+	Profile string
+	Flags   *flag.FlagSet
+}
+
+// CommandSpec describes a command for help, validation and flag parsing.
+type CommandSpec struct {
+	Name    string
+	Summary string
+	// Args names the positional arguments. "[name]" is optional and a
+	// trailing "..." takes the rest of the arguments.
 	Args []string
+	// Flags declares the command's flags, they may appear anywhere
+	Flags func(fs *flag.FlagSet)
+	// Offline commands run without a database connection
+	Offline bool
+	// OptionalDB commands get a database when it is reachable and run
+	// without one otherwise
+	OptionalDB bool
+	// AnySchema commands run even when migrations are pending
+	AnySchema bool
+	// Hidden commands are left out of help and completion
+	Hidden bool
+	// RawArgs commands get their arguments without flag parsing
+	RawArgs bool
+	// Complete suggests values for the positional argument at index
+	Complete func(ctx context.Context, s *config.State, index int) ([]string, error)
+	Handler  func(context.Context, *config.State, Command) error
 }
 
 type Commands struct {
-	Commands map[string]func(*config.State, Command) error
+	Commands map[string]CommandSpec
+}
+
+// UsageError is returned when a command was called with the wrong
+// arguments, as opposed to failing while it ran.
+type UsageError struct {
+	Command string
+	Err     error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func usageErrorf(cmd Command, format string, args ...any) error {
+	return &UsageError{Command: cmd.Name, Err: fmt.Errorf(format, args...)}
+}
+
+// unknownCommand has no command to point at for usage, so help lists them all.
+func unknownCommand(name string) error {
+	return &UsageError{Err: fmt.Errorf("Command '%v' does not exists", name)}
+}
+
+// PrintError prints err, pointing usage errors at the relevant help.
+func PrintError(w io.Writer, err error) {
+	fmt.Fprintln(w, err)
+	var usageErr *UsageError
+	if !errors.As(err, &usageErr) {
+		return
+	}
+	if usageErr.Command == "" {
+		fmt.Fprintln(w, "Run 'gator help' for a list of commands.")
+		return
+	}
+	fmt.Fprintf(w, "Run 'gator help %v' for usage.\n", usageErr.Command)
+}
+
+// ExitCode maps the error returned by Run to the process exit code.
+func ExitCode(err error) int {
+	var usageErr *UsageError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &usageErr):
+		return ExitUsage
+	default:
+		return ExitFailure
+	}
+}
+
+// globalFlags may also be given before the command name, as in
+// "gator --profile team browse".
+var globalFlags = []string{"config", "profile", "output", "o"}
+
+// ParseCommand splits the command line into a command and its arguments.
+// Global flags before the command name are passed on to the command.
+func ParseCommand(input []string) (Command, error) {
+	global := []string{}
+	for len(input) > 0 && strings.HasPrefix(input[0], "-") {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(input[0], "-"), "=")
+		if !slices.Contains(globalFlags, name) {
+			return Command{}, &UsageError{Err: fmt.Errorf("Unknown flag '%v' before the command", input[0])}
+		}
+		if !hasValue {
+			if len(input) < 2 {
+				return Command{}, &UsageError{Err: fmt.Errorf("Flag '%v' needs a value", input[0])}
+			}
+			global = append(global, input[0])
+			input = input[1:]
+		}
+		global = append(global, input[0])
+		input = input[1:]
+	}
+
+	if len(input) == 0 {
+		return Command{}, &UsageError{Err: fmt.Errorf("Too few arguements")}
+	}
+	return Command{Name: input[0], Args: append(global, input[1:]...)}, nil
 }
 
-func (c *Commands) Run(state *config.State, cmd Command) error {
-	val, exists := c.Commands[cmd.Name]
+// Spec returns the registered spec of a command.
+func (c *Commands) Spec(name string) (CommandSpec, bool) {
+	spec, exists := c.Commands[name]
+	return spec, exists
+}
+
+func (c *Commands) Run(ctx context.Context, state *config.State, cmd Command) error {
+	cmd, err := c.Parse(cmd)
+	if err == flag.ErrHelp {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return c.Exec(ctx, state, cmd)
+}
+
+// Parse validates the arguments and parses the flags of cmd. It prints the
+// command's usage and returns flag.ErrHelp when asked for -h.
+func (c *Commands) Parse(cmd Command) (Command, error) {
+	spec, exists := c.Commands[cmd.Name]
 	if !exists {
-		return fmt.Errorf("Command '%v' does not exists", cmd.Name)
+		return cmd, unknownCommand(cmd.Name)
+	}
+
+	if spec.RawArgs {
+		cmd.Output = output.Text
+		return cmd, nil
+	}
+
+	fs := spec.flagSet()
+	positional, err := parseInterspersed(fs, cmd.Args)
+	if err == flag.ErrHelp {
+		spec.printUsage(os.Stdout)
+		return cmd, err
+	}
+	if err != nil {
+		return cmd, usageErrorf(cmd, "%v", err)
+	}
+
+	cmd.Args = positional
+	cmd.Flags = fs
+	cmd.Output = *fs.Lookup("output").Value.(*output.Format)
+	cmd.Config = cmd.stringFlag("config")
+	cmd.Profile = cmd.stringFlag("profile")
+	return cmd, spec.checkArgs(cmd)
+}
+
+// Exec runs a command returned by Parse.
+func (c *Commands) Exec(ctx context.Context, state *config.State, cmd Command) error {
+	return c.Commands[cmd.Name].Handler(ctx, state, cmd)
+}
+
+func (c *Commands) Register(spec CommandSpec, f func(context.Context, *config.State, Command) error) {
+	spec.Handler = f
+	c.Commands[spec.Name] = spec
+}
+
+// HandlerHelp lists the commands, or describes a single one.
+func (c *Commands) HandlerHelp(ctx context.Context, s *config.State, cmd Command) error {
+	if len(cmd.Args) == 1 {
+		spec, exists := c.Commands[cmd.Args[0]]
+		if !exists {
+			return unknownCommand(cmd.Args[0])
+		}
+		spec.printUsage(os.Stdout)
+		return nil
 	}
 
-	return val(state, cmd)
+	c.PrintCommands(os.Stdout)
+	return nil
+}
+
+// PrintCommands writes the overview shown by help.
+func (c *Commands) PrintCommands(w io.Writer) {
+	names := c.Names()
+
+	fmt.Fprintln(w, "Usage: gator <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %v\t%v\n", name, c.Commands[name].Summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'gator help <command>' for details on a command.")
+}
+
+// Names returns the sorted names of the commands that aren't hidden.
+func (c *Commands) Names() []string {
+	names := []string{}
+	for name, spec := range c.Commands {
+		if !spec.Hidden {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (spec CommandSpec) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(spec.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if spec.Flags != nil {
+		spec.Flags(fs)
+	}
+
+	format := output.Text
+	fs.Var(&format, "output", "output `format`: text, table, json, ndjson or csv")
+	fs.Var(&format, "o", "output `format`, shorthand for -output")
+	fs.String("config", "", "config `file` to use instead of the default")
+	fs.String("profile", "", "config `profile` to use instead of the current one")
+	return fs
+}
+
+// Usage is the one line synopsis of the command.
+func (spec CommandSpec) Usage() string {
+	usage := "gator " + spec.Name
+	if spec.Flags != nil {
+		usage += " [flags]"
+	}
+	for _, arg := range spec.Args {
+		if strings.HasPrefix(arg, "[") {
+			usage += " " + arg
+			continue
+		}
+		usage += " <" + arg + ">"
+	}
+	return usage
+}
+
+func (spec CommandSpec) printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %v\n\n%v\n\nFlags:\n", spec.Usage(), spec.Summary)
+	fs := spec.flagSet()
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
+
+// checkArgs validates the number of positional arguments against the spec.
+func (spec CommandSpec) checkArgs(cmd Command) error {
+	required, variadic := 0, false
+	for _, arg := range spec.Args {
+		if !strings.HasPrefix(arg, "[") {
+			required++
+		}
+		if strings.HasSuffix(strings.TrimSuffix(arg, "]"), "...") {
+			variadic = true
+		}
+	}
+
+	switch {
+	case len(cmd.Args) < required:
+		return usageErrorf(cmd, "Too few arguments. Usage: %v", spec.Usage())
+	case !variadic && len(cmd.Args) > len(spec.Args):
+		return usageErrorf(cmd, "Too many arguments. Usage: %v", spec.Usage())
+	}
+	return nil
+}
+
+// parseInterspersed parses flags that appear before, between or after the
+// positional arguments, which the flag package alone stops at. Everything
+// after "--" is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if len(rest) < len(args) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// notef prints a message meant for people. In the machine readable formats
+// it goes to stderr so stdout stays parseable.
+func (c Command) notef(format string, args ...any) {
+	w := os.Stdout
+	if c.Output.Machine() {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, format, args...)
+}
+
+// flag returns the parsed value of a flag, or nil if the command has none
+// by that name.
+func (c Command) flag(name string) any {
+	if c.Flags == nil {
+		return nil
+	}
+	f := c.Flags.Lookup(name)
+	if f == nil {
+		return nil
+	}
+	return f.Value.(flag.Getter).Get()
+}
+
+// isSet reports whether the flag was given on the command line, rather
+// than left at its default.
+func (c Command) isSet(name string) bool {
+	set := false
+	if c.Flags != nil {
+		c.Flags.Visit(func(f *flag.Flag) {
+			if f.Name == name {
+				set = true
+			}
+		})
+	}
+	return set
+}
+
+func (c Command) boolFlag(name string) bool {
+	value, _ := c.flag(name).(bool)
+	return value
+}
+
+func (c Command) intFlag(name string) int {
+	value, _ := c.flag(name).(int)
+	return value
+}
+
+func (c Command) stringFlag(name string) string {
+	value, _ := c.flag(name).(string)
+	return value
+}
+
+func (c Command) durationFlag(name string) time.Duration {
+	value, _ := c.flag(name).(time.Duration)
+	return value
 }
 
-func (c *Commands) Register(name string, f func(*config.State, Command) error) {
-	c.Commands[name] = f
+// render prints records in the format cmd was asked for.
+func render[T output.Record](cmd Command, records []T) error {
+	return output.Render(os.Stdout, cmd.Output, records)
 }
@@ -0,0 +1,93 @@
+package handling_test
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/wfcornelissen/blogag/internal/handling"
+	"github.com/wfcornelissen/blogag/internal/output"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		input []string
+		name  string
+		args  []string
+		code  int
+	}{
+		{[]string{"browse", "5"}, "browse", []string{"5"}, handling.ExitOK},
+		{[]string{"--profile", "team", "browse", "5"}, "browse", []string{"--profile", "team", "5"}, handling.ExitOK},
+		{[]string{"-o=json", "feeds"}, "feeds", []string{"-o=json"}, handling.ExitOK},
+		{[]string{"--all", "browse"}, "", nil, handling.ExitUsage},
+		{[]string{"--profile"}, "", nil, handling.ExitUsage},
+		{[]string{}, "", nil, handling.ExitUsage},
+	}
+	for _, test := range tests {
+		cmd, err := handling.ParseCommand(test.input)
+		if code := handling.ExitCode(err); code != test.code {
+			t.Errorf("ParseCommand(%q): exit code %v (%v), want %v", test.input, code, err, test.code)
+			continue
+		}
+		if err == nil && (cmd.Name != test.name || !reflect.DeepEqual(cmd.Args, test.args)) {
+			t.Errorf("ParseCommand(%q) = %v %q, want %v %q", test.input, cmd.Name, cmd.Args, test.name, test.args)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	cmds := handling.Commands{Commands: map[string]handling.CommandSpec{}}
+	flags := func(fs *flag.FlagSet) {
+		fs.Bool("all", false, "")
+		fs.String("feed", "", "")
+	}
+	cmds.Register(handling.CommandSpec{Name: "one", Args: []string{"name", "[url]"}, Flags: flags}, nil)
+	cmds.Register(handling.CommandSpec{Name: "many", Args: []string{"words..."}, Flags: flags}, nil)
+
+	tests := []struct {
+		name   string
+		args   []string
+		want   []string
+		all    string
+		feed   string
+		format output.Format
+		code   int
+	}{
+		{"flags first", []string{"one", "--all", "a"}, []string{"a"}, "true", "", output.Text, handling.ExitOK},
+		{"flags after positional", []string{"one", "a", "b", "--feed", "x"}, []string{"a", "b"}, "false", "x", output.Text, handling.ExitOK},
+		{"flags between positional", []string{"one", "a", "-all", "b"}, []string{"a", "b"}, "true", "", output.Text, handling.ExitOK},
+		{"flag=value", []string{"one", "a", "-feed=x y", "--output=csv"}, []string{"a"}, "false", "x y", output.CSV, handling.ExitOK},
+		{"output shorthand", []string{"one", "a", "-o", "json"}, []string{"a"}, "false", "", output.JSON, handling.ExitOK},
+		{"bool flag=false", []string{"one", "--all=false", "a"}, []string{"a"}, "false", "", output.Text, handling.ExitOK},
+		{"double dash", []string{"many", "a", "--", "--all", "-o", "json"}, []string{"a", "--all", "-o", "json"}, "false", "", output.Text, handling.ExitOK},
+		{"double dash first", []string{"many", "--feed", "x", "--", "-1"}, []string{"-1"}, "false", "x", output.Text, handling.ExitOK},
+		{"variadic", []string{"many", "a", "b", "c"}, []string{"a", "b", "c"}, "false", "", output.Text, handling.ExitOK},
+		{"too few arguments", []string{"one", "--all"}, nil, "", "", "", handling.ExitUsage},
+		{"too many arguments", []string{"one", "a", "b", "c"}, nil, "", "", "", handling.ExitUsage},
+		{"unknown flag", []string{"one", "a", "--nope"}, nil, "", "", "", handling.ExitUsage},
+		{"missing flag value", []string{"one", "a", "--feed"}, nil, "", "", "", handling.ExitUsage},
+		{"invalid bool", []string{"one", "a", "--all=maybe"}, nil, "", "", "", handling.ExitUsage},
+		{"unknown format", []string{"one", "a", "-o", "xml"}, nil, "", "", "", handling.ExitUsage},
+		{"unknown command", []string{"none"}, nil, "", "", "", handling.ExitUsage},
+	}
+	for _, test := range tests {
+		cmd, err := handling.ParseCommand(test.args)
+		if err == nil {
+			cmd, err = cmds.Parse(cmd)
+		}
+		if code := handling.ExitCode(err); code != test.code {
+			t.Errorf("%v: exit code %v (%v), want %v", test.name, code, err, test.code)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(cmd.Args, test.want) {
+			t.Errorf("%v: args = %q, want %q", test.name, cmd.Args, test.want)
+		}
+		all, feed := cmd.Flags.Lookup("all").Value.String(), cmd.Flags.Lookup("feed").Value.String()
+		if all != test.all || feed != test.feed || cmd.Output != test.format {
+			t.Errorf("%v: all = %v, feed = %q, output = %v, want %v, %q, %v", test.name, all, feed, cmd.Output, test.all, test.feed, test.format)
+		}
+	}
+}
@@ -0,0 +1,638 @@
+package handling_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wfcornelissen/blogag/internal/config"
+	"github.com/wfcornelissen/blogag/internal/database"
+	"github.com/wfcornelissen/blogag/internal/handling"
+	"github.com/wfcornelissen/blogag/internal/memdb"
+	"github.com/wfcornelissen/blogag/internal/middleware"
+	"github.com/wfcornelissen/blogag/internal/migrate"
+	"github.com/wfcornelissen/blogag/internal/sqlite"
+)
+
+// testEnv runs commands the way main does, against one of the backends and
+// a config file in a temporary directory.
+type testEnv struct {
+	t     *testing.T
+	cmds  handling.Commands
+	state *config.State
+}
+
+// backends opens an empty database for each kind handlers run against,
+// setting the database fields of the state.
+var backends = []struct {
+	name string
+	open func(t *testing.T) config.State
+}{
+	{"memdb", func(t *testing.T) config.State { return config.State{Db: memdb.New()} }},
+	{"sqlite", openSQLite},
+}
+
+// openSQLite migrates a new SQLite file with the migrations in sql/sqlite.
+func openSQLite(t *testing.T) config.State {
+	t.Helper()
+	conn, err := sqlite.Open(filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	root := os.DirFS("../..")
+	migrations, err := migrate.Load(root, "sql/sqlite/schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrate.Up(context.Background(), conn, migrate.Schema{Dialect: migrate.SQLite, Migrations: migrations})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries, err := sqlite.New(conn, root, "sql/sqlite/queries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.State{
+		Db:     database.New(queries),
+		Conn:   conn,
+		WithTx: func(tx *sql.Tx) database.Querier { return database.New(queries.WithTx(tx)) },
+	}
+}
+
+// forEachBackend runs test as a subtest against every backend.
+func forEachBackend(t *testing.T, test func(t *testing.T, e *testEnv)) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			test(t, newTestEnv(t, backend.open(t)))
+		})
+	}
+}
+
+func newTestEnv(t *testing.T, state config.State) *testEnv {
+	t.Helper()
+	for _, name := range []string{config.ProfileEnv, "GATOR_DB_URL", "GATOR_CURRENT_USER_NAME"} {
+		t.Setenv(name, "")
+	}
+
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "config.json"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmds := handling.Commands{Commands: map[string]handling.CommandSpec{}}
+	cmds.Register(handling.LoginCommand, handling.HandlerLogin)
+	cmds.Register(handling.RegisterCommand, handling.HandlerRegister)
+	cmds.Register(handling.UsersCommand, handling.HandlerUsers)
+	cmds.Register(handling.AddFeedCommand, middleware.MiddlewareLoggedIn(handling.HandlerAddFeed))
+	cmds.Register(handling.FeedsCommand, handling.HandlerFeeds)
+	cmds.Register(handling.FollowCommand, middleware.MiddlewareLoggedIn(handling.HandlerFollow))
+	cmds.Register(handling.FollowingCommand, middleware.MiddlewareLoggedIn(handling.HandlerFollowing))
+	cmds.Register(handling.UnfollowCommand, middleware.MiddlewareLoggedIn(handling.HandlerUnfollow))
+	cmds.Register(handling.BrowseCommand, middleware.MiddlewareLoggedIn(handling.HandlerBrowse))
+	cmds.Register(handling.ReadCommand, middleware.MiddlewareLoggedIn(handling.HandlerRead))
+	cmds.Register(handling.AggCommand, handling.HandlerAgg)
+	cmds.Register(handling.ImportOPMLCommand, middleware.MiddlewareLoggedIn(handling.HandlerImportOPML))
+
+	state.State = &cfg
+	return &testEnv{
+		t:     t,
+		cmds:  cmds,
+		state: &state,
+	}
+}
+
+// run executes a command line and returns what it wrote to stdout.
+func (e *testEnv) run(args ...string) (string, error) {
+	e.t.Helper()
+	return e.runContext(context.Background(), args...)
+}
+
+// runContext is run with a context, which stops commands such as agg.
+func (e *testEnv) runContext(ctx context.Context, args ...string) (string, error) {
+	e.t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+
+	cmd, err := handling.ParseCommand(args)
+	if err == nil {
+		err = e.cmds.Run(ctx, e.state, cmd)
+	}
+	w.Close()
+	return <-out, err
+}
+
+// mustRun runs a command line that is expected to succeed.
+func (e *testEnv) mustRun(args ...string) string {
+	e.t.Helper()
+	out, err := e.run(args...)
+	if err != nil {
+		e.t.Fatalf("%v: %v", strings.Join(args, " "), err)
+	}
+	return out
+}
+
+// runJSON runs a command line with -o json and decodes its output into v.
+func (e *testEnv) runJSON(v any, args ...string) {
+	e.t.Helper()
+	out := e.mustRun(append(args, "-o", "json")...)
+	err := json.Unmarshal([]byte(out), v)
+	if err != nil {
+		e.t.Fatalf("%v: decoding %q: %v", strings.Join(args, " "), out, err)
+	}
+}
+
+type testItem struct {
+	title     string
+	published time.Time
+}
+
+// newFeedServer serves an RSS feed with the given items. An item without
+// a published time gets a date that doesn't parse.
+func newFeedServer(t *testing.T, title string, items ...testItem) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>%v</title><link>http://%v/</link>`, title, r.Host)
+		for _, item := range items {
+			slug := strings.ReplaceAll(strings.ToLower(item.title), " ", "-")
+			published := "some day"
+			if !item.published.IsZero() {
+				published = item.published.Format(time.RFC1123Z)
+			}
+			fmt.Fprintf(w, `<item><title>%v</title><link>http://%v/%v</link><description>About %v</description><pubDate>%v</pubDate></item>`,
+				item.title, r.Host, slug, item.title, published)
+		}
+		fmt.Fprint(w, `</channel></rss>`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, e *testEnv) {
+		e.mustRun("register", "alice")
+		if e.state.State.CurrentUserName != "alice" {
+			t.Fatalf("current user = %q after register, want alice", e.state.State.CurrentUserName)
+		}
+		if _, err := e.run("register", "alice"); err == nil {
+			t.Fatal("registering alice twice succeeded")
+		}
+
+		e.mustRun("register", "bob")
+		e.mustRun("login", "alice")
+		if e.state.State.CurrentUserName != "alice" {
+			t.Fatalf("current user = %q after login, want alice", e.state.State.CurrentUserName)
+		}
+		if _, err := e.run("login", "carol"); err == nil {
+			t.Fatal("logging in as an unknown user succeeded")
+		}
+
+		// The login is saved to the config file
+		saved, err := config.Load(e.state.State.Path(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.CurrentUserName != "alice" {
+			t.Errorf("saved current user = %q, want alice", saved.CurrentUserName)
+		}
+
+		var users []handling.UserView
+		e.runJSON(&users, "users")
+		want := []handling.UserView{{Name: "alice", Current: true}, {Name: "bob"}}
+		if fmt.Sprint(users) != fmt.Sprint(want) {
+			t.Errorf("users = %v, want %v", users, want)
+		}
+	})
+}
+
+func TestLoggedInCommandsNeedAUser(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, e *testEnv) {
+		if _, err := e.run("following"); err == nil {
+			t.Fatal("following succeeded without a logged in user")
+		}
+	})
+}
+
+func TestAddFeed(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, e *testEnv) {
+		now := time.Now().UTC().Truncate(time.Second)
+		srv := newFeedServer(t, "Test Blog",
+			testItem{"First Post", now.Add(-2 * time.Hour)},
+			testItem{"Second Post", now.Add(-time.Hour)},
+		)
+
+		e.mustRun("register", "alice")
+		e.mustRun("addfeed", srv.URL)
+
+		var feeds []handling.FeedView
+		e.runJSON(&feeds, "feeds")
+		if len(feeds) != 1 || feeds[0].Name != "Test Blog" || feeds[0].URL != srv.URL || feeds[0].User != "alice" {
+			t.Fatalf("feeds = %v, want Test Blog at %v added by alice", feeds, srv.URL)
+		}
+
+		var following []handling.FollowView
+		e.runJSON(&following, "following")
+		if len(following) != 1 || following[0].Feed != "Test Blog" || following[0].Unread != 2 {
+			t.Fatalf("following = %v, want Test Blog with 2 unread posts", following)
+		}
+
+		// agg picks the feed up on its schedule, not straight away
+		feed, err := e.state.Db.GetFeedByURL(context.Background(), sql.NullString{String: srv.URL, Valid: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !feed.NextFetchAt.Valid || !feed.NextFetchAt.Time.After(now) {
+			t.Errorf("next fetch at = %v, want after %v", feed.NextFetchAt, now)
+		}
+
+		// The name is taken, even with another URL
+		other := newFeedServer(t, "Test Blog")
+		if _, err := e.run("addfeed", other.URL); err == nil {
+			t.Error("adding a second feed with the same name succeeded")
+		}
+		if _, err := e.run("addfeed", "Renamed", srv.URL); err == nil {
+			t.Error("adding the same feed twice succeeded")
+		}
+	})
+}
+
+func TestAddFeedNameTaken(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, e *testEnv) {
+		first := newFeedServer(t, "Test Blog")
+		second := newFeedServer(t, "Test Blog")
+		e.mustRun("register", "alice")
+		e.mustRun("addfeed", first.URL)
+
+		_, err := e.run("addfeed", second.URL)
+		if err == nil || !strings.Contains(err.Error(), "gator addfeed <name> "+second.URL) {
+			t.Fatalf("addfeed with a taken title: err = %v, want a hint to name the feed", err)
+		}
+		_, err = e.run("addfeed", "Test Blog", second.URL)
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Fatalf("addfeed with a taken name: err = %v, want already exists", err)
+		}
+
+		e.mustRun("addfeed", "Other Blog", second.URL)
+		var feeds []handling.FeedView
+		e.runJSON(&feeds, "feeds")
+		if len(feeds) != 2 {
+			t.Errorf("feeds = %v, want both blogs", feeds)
+		}
+	})
+}
+
+func TestAddFeedFromSiteKeepsStdoutClean(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, e *testEnv) {
+		feed := newFeedServer(t, "Test Blog", testItem{"First Post", time.Now().UTC()})
+		site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><head><link rel="alternate" type="application/rss+xml" href="%v"></head></html>`, feed.URL)
+		}))
+		t.Cleanup(site.Close)
+
+		// "Found feed" is a note for people, not part of the json output
+		e.mustRun("register", "alice")
+		out := e.mustRun("addfeed", site.URL, "-o", "json")
+		if out != "" {
+			t.Errorf("addfeed -o json wrote %q to stdout, want nothing", out)
+		}
+	})
+}
+
+func TestAddFeedFetchesOnce(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, e *testEnv) {
+		var requests atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Header().Set("ETag", `"v1"`)
+			fmt.Fprint(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Once</title></channel></rss>`)
+		}))
+		t.Cleanup(srv.Close)
+
+		e.mustRun("register", "alice")
+		e.mustRun("addfeed", srv.URL)
+		if n := requests.Load(); n != 1 {
+			t.Errorf("addfeed requested the feed %v times, want once", n)
+		}
+
+		// The validators of the one response are kept for agg
+		feed, err := e.state.Db.GetFeedByURL(context.Background(), sql.NullString{String: srv.URL, Valid: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if feed.Etag.String != `"v1"` {
+			t.Errorf("etag = %q, want \"v1\"", feed.Etag.String)
+		}
+	})
+}
+
+// failingPosts fails every post insert.
+type failingPosts struct {
+	database.Querier
+}
+
+func (failingPosts) CreatePost(ctx context.Context, arg database.CreatePostParams) (int64, error) {
+	return 0, fmt.Errorf("disk full")
+}
+
+func TestAddFeedRollsBack(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, e *testEnv) {
+		if e.state.WithTx == nil {
+			t.Skip("no transactions")
+		}
+		srv := newFeedServer(t, "Test Blog", testItem{"First Post", time.Now()})
+		e.mustRun("register", "alice")
+
+		withTx := e.state.WithTx
+		e.state.WithTx = func(tx *sql.Tx) database.Querier { return failingPosts{withTx(tx)} }
+		if _, err := e.run("addfeed", srv.URL); err == nil {
+			t.Fatal("addfeed succeeded without storing its posts")
+		}
+		var feeds []handling.FeedView
+		e.runJSON(&feeds, "feeds")
+		if len(feeds) != 0 {
+			t.Fatalf("feeds = %v after a failed addfeed, want none", feeds)
+		}
+
+		e.state.WithTx = withTx
+		e.mustRun("addfeed", srv.URL)
+	})
+}
+
+func TestAggRetriesPostsItFailedToStore(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, e *testEnv) {
+		now := time.Now().UTC().Truncate(time.Second)
+		var version atomic.Int32
+		version.Store(1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			etag := fmt.Sprintf(`"v%v"`, version.Load())
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Header().Set("ETag", etag)
+			fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Blog</title>`)
+			for i := int32(1); i <= version.Load(); i++ {
+				fmt.Fprintf(w, `<item><title>Post %v</title><link>http://%v/%v</link><pubDate>%v</pubDate></item>`,
+					i, r.Host, i, now.Add(time.Duration(i)*time.Minute).Format(time.RFC1123Z))
+			}
+			fmt.Fprint(w, `</channel></rss>`)
+		}))
+		t.Cleanup(srv.Close)
+
+		e.mustRun("register", "alice")
+		e.mustRun("addfeed", srv.URL)
+		version.Store(2)
+
+		ctx := context.Background()
+		feedURL := sql.NullString{String: srv.URL, Valid: true}
+		agg := func() {
+			t.Helper()
+			feed, err := e.state.Db.GetFeedByURL(ctx, feedURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = e.state.Db.SetFeedNextFetchAt(ctx, database.SetFeedNextFetchAtParams{
+				NextFetchAt: sql.NullTime{Time: now.Add(-time.Hour), Valid: true},
+				ID:          feed.ID,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// agg fetches the due feeds straight away, then waits for the tick
+			stop, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+			defer cancel()
+			_, err = e.runContext(stop, "agg", "1h")
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		db, withTx := e.state.Db, e.state.WithTx
+		e.state.Db = failingPosts{db}
+		if withTx != nil {
+			e.state.WithTx = func(tx *sql.Tx) database.Querier { return failingPosts{withTx(tx)} }
+		}
+		agg()
+		feed, err := db.GetFeedByURL(ctx, feedURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if feed.Etag.String != `"v1"` {
+			t.Fatalf("etag = %q after the posts failed to store, want \"v1\"", feed.Etag.String)
+		}
+
+		// The next run downloads the feed again instead of getting a 304
+		e.state.Db, e.state.WithTx = db, withTx
+		agg()
+		var following []handling.FollowView
+		e.runJSON(&following, "following")
+		if len(following) != 1 || following[0].Unread != 2 {
+			t.Errorf("following = %v, want Blog with 2 unread posts", following)
+		}
+	})
+}
+
+func TestBrowseUndatedLast(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, e *testEnv) {
+		now := time.Now().UTC().Truncate(time.Second)
+		srv := newFeedServer(t, "Test Blog",
+			testItem{"Undated", time.Time{}},
+			testItem{"Old", now.Add(-48 * time.Hour)},
+			testItem{"New", now.Add(-time.Hour)},
+		)
+		e.mustRun("register", "alice")
+		e.mustRun("addfeed", srv.URL)
+
+		// An undated post is stored now, but still sorts after every dated one
+		var titles []string
+		var posts []handling.PostView
+		e.runJSON(&posts, "browse", "1")
+		for len(posts) == 1 {
+			titles = append(titles, posts[0].Title)
+			e.runJSON(&posts, "browse", "1", "--before", posts[0].Cursor)
+		}
+		if got := strings.Join(titles, ", "); got != "New, Old, Undated" {
+			t.Errorf("browse pages = %v, want New, Old, Undated", got)
+		}
+	})
+}
+
+func TestImportOPML(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, e *testEnv) {
+		e.mustRun("register", "alice")
+
+		path := filepath.Join(t.TempDir(), "feeds.opml")
+		err := os.WriteFile(path, []byte(`<?xml version="1.0"?>
+<opml version="2.0"><head/><body>
+  <outline text="Work"><outline text="Blog" xmlUrl="https://work.example/rss"/></outline>
+  <outline text="Home"><outline text="Blog" xmlUrl="https://home.example/rss"/></outline>
+  <outline text="Broken" xmlUrl="https://broken.example/rss"/>
+</body></opml>`), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Take the name the third feed would get
+		e.mustRun("addfeed", newFeedServer(t, "Broken").URL)
+
+		out := e.mustRun("import-opml", path)
+		if !strings.Contains(out, "Imported 3 of 3 feeds: 3 created, 3 newly followed") {
+			t.Errorf("import summary:\n%v", out)
+		}
+
+		var feeds []handling.FeedView
+		e.runJSON(&feeds, "feeds")
+		names := []string{}
+		for _, feed := range feeds {
+			names = append(names, feed.Name)
+		}
+		sort.Strings(names)
+		want := []string{"Blog", "Blog (home.example)", "Broken", "Broken (broken.example)"}
+		if fmt.Sprint(names) != fmt.Sprint(want) {
+			t.Errorf("feeds = %v, want %v", names, want)
+		}
+	})
+}
+
+func TestFollowAndUnfollow(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, e *testEnv) {
+		srv := newFeedServer(t, "Test Blog", testItem{"First Post", time.Now()})
+
+		e.mustRun("register", "alice")
+		e.mustRun("addfeed", srv.URL)
+		e.mustRun("register", "bob")
+
+		var following []handling.FollowView
+		e.runJSON(&following, "following")
+		if len(following) != 0 {
+			t.Fatalf("bob follows %v before following anything", following)
+		}
+
+		// Feeds can be followed by name or URL
+		e.mustRun("follow", "Test Blog")
+		e.runJSON(&following, "following")
+		if len(following) != 1 || following[0].URL != srv.URL {
+			t.Fatalf("following = %v after follow, want %v", following, srv.URL)
+		}
+		if _, err := e.run("follow", srv.URL); err == nil {
+			t.Error("following a feed twice succeeded")
+		}
+		if _, err := e.run("follow", "No Such Feed"); err == nil {
+			t.Error("following an unknown feed succeeded")
+		}
+
+		e.mustRun("unfollow", srv.URL)
+		e.runJSON(&following, "following")
+		if len(following) != 0 {
+			t.Errorf("following = %v after unfollow, want none", following)
+		}
+
+		// Alice still follows the feed she added
+		e.mustRun("login", "alice")
+		e.runJSON(&following, "following")
+		if len(following) != 1 {
+			t.Errorf("alice follows %v, want the feed she added", following)
+		}
+	})
+}
+
+func TestBrowse(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, e *testEnv) {
+		now := time.Now().UTC().Truncate(time.Second)
+		srv := newFeedServer(t, "Test Blog",
+			testItem{"Oldest", now.Add(-3 * time.Hour)},
+			testItem{"Newest", now.Add(-time.Hour)},
+			testItem{"Middle", now.Add(-2 * time.Hour)},
+		)
+		other := newFeedServer(t, "Other Blog", testItem{"Elsewhere", now})
+
+		e.mustRun("register", "alice")
+		e.mustRun("addfeed", srv.URL)
+		e.mustRun("addfeed", other.URL)
+		e.mustRun("unfollow", other.URL)
+
+		titles := func(posts []handling.PostView) string {
+			names := []string{}
+			for _, post := range posts {
+				names = append(names, post.Title)
+			}
+			return strings.Join(names, ", ")
+		}
+
+		// Newest first, limited, and only from followed feeds
+		var posts []handling.PostView
+		e.runJSON(&posts, "browse")
+		if got := titles(posts); got != "Newest, Middle" {
+			t.Fatalf("browse = %v, want Newest, Middle", got)
+		}
+
+		// The cursor of the last post continues after it
+		e.runJSON(&posts, "browse", "--before", posts[1].Cursor)
+		if got := titles(posts); got != "Oldest" {
+			t.Fatalf("browse --before = %v, want Oldest", got)
+		}
+
+		// Read posts are left out unless --all is given
+		e.mustRun("read", posts[0].URL)
+		e.runJSON(&posts, "browse", "10")
+		if got := titles(posts); got != "Newest, Middle" {
+			t.Errorf("browse after read = %v, want Newest, Middle", got)
+		}
+		e.runJSON(&posts, "browse", "10", "--all")
+		if got := titles(posts); got != "Newest, Middle, Oldest" {
+			t.Errorf("browse --all = %v, want Newest, Middle, Oldest", got)
+		}
+		e.runJSON(&posts, "browse", "10", "--unread=false")
+		if got := titles(posts); got != "Newest, Middle, Oldest" {
+			t.Errorf("browse --unread=false = %v, want Newest, Middle, Oldest", got)
+		}
+
+		e.runJSON(&posts, "browse", "10", "--all", "--since", "150m")
+		if got := titles(posts); got != "Newest, Middle" {
+			t.Errorf("browse --since = %v, want Newest, Middle", got)
+		}
+
+		// The next page keeps the limit and filters of the first
+		out := e.mustRun("browse", "1", "--all", "--feed", srv.URL, "--since", "150m")
+		_, next, found := strings.Cut(out, "Next page: gator ")
+		if !found {
+			t.Fatalf("browse printed no next page command:\n%v", out)
+		}
+		e.runJSON(&posts, strings.Fields(next)...)
+		if got := titles(posts); got != "Middle" {
+			t.Errorf("%v = %v, want Middle", strings.TrimSpace(next), got)
+		}
+
+		_, err := e.run("browse", "none")
+		if handling.ExitCode(err) != handling.ExitUsage {
+			t.Errorf("browse with an invalid limit returned %v, want a usage error", err)
+		}
+	})
+}
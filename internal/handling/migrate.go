@@ -0,0 +1,75 @@
+package handling
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wfcornelissen/blogag/internal/config"
+	"github.com/wfcornelissen/blogag/internal/migrate"
+)
+
+// HandlerMigrate manages the schema of the database, with the migrations
+// embedded in the binary for its kind.
+func HandlerMigrate(ctx context.Context, s *config.State, cmd Command) error {
+	switch cmd.Args[0] {
+	case "up":
+		applied, err := migrate.Up(ctx, s.Conn, s.Schema)
+		for _, migration := range applied {
+			cmd.notef("Applied %v\n", migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			cmd.notef("Database is up to date\n")
+		}
+
+	case "down":
+		migration, err := migrate.Down(ctx, s.Conn, s.Schema)
+		if err != nil {
+			return err
+		}
+		if migration == nil {
+			cmd.notef("No migrations to roll back\n")
+			return nil
+		}
+		cmd.notef("Rolled back %v\n", migration.Name)
+
+	case "status":
+		statuses, err := migrate.Statuses(ctx, s.Conn, s.Schema)
+		if err != nil {
+			return err
+		}
+		views := []MigrationView{}
+		for _, status := range statuses {
+			views = append(views, newMigrationView(status))
+		}
+		return render(cmd, views)
+
+	case "version":
+		version, err := migrate.Version(ctx, s.Conn, s.Schema.Dialect)
+		if err != nil {
+			return err
+		}
+		fmt.Println(version)
+
+	default:
+		return usageErrorf(cmd, "Unknown action '%s'. Expected up, down, status or version", cmd.Args[0])
+	}
+	return nil
+}
+
+// CheckSchema refuses to run against a database that is missing migrations,
+// queries would otherwise fail halfway with confusing errors.
+func CheckSchema(ctx context.Context, s *config.State) error {
+	pending, err := migrate.Pending(ctx, s.Conn, s.Schema)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("Database schema is out of date, %v migrations are pending (up to %v).\nRun 'gator migrate up' to apply them.",
+		len(pending), pending[len(pending)-1].Name)
+}
@@ -0,0 +1,171 @@
+package handling
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/config"
+	"github.com/wfcornelissen/blogag/internal/database"
+	"github.com/wfcornelissen/blogag/internal/opml"
+)
+
+func HandlerImportOPML(ctx context.Context, s *config.State, cmd Command, user database.User) error {
+	file, err := os.Open(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("Failed to open OPML file:\n%v\n", err)
+	}
+	defer file.Close()
+
+	subs, err := opml.Parse(file)
+	if err != nil {
+		return err
+	}
+
+	following, err := s.Db.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve follows for user id:\n%v\n", err)
+	}
+	followed := map[uuid.UUID]bool{}
+	for _, follow := range following {
+		followed[follow.FeedID] = true
+	}
+
+	// A feed is skipped when it can be neither found nor created and
+	// followed, the summary counts what actually happened
+	created, followedCount := 0, 0
+	skipped := []string{}
+	for _, sub := range subs {
+		feed, err := s.Db.GetFeedByURL(ctx, sql.NullString{String: sub.URL, Valid: true})
+		if err == sql.ErrNoRows {
+			var name string
+			name, err = uniqueFeedName(ctx, s, sub.Name, sub.URL)
+			if err == nil {
+				feed, err = s.Db.CreateFeed(ctx, database.CreateFeedParams{
+					ID:        uuid.New(),
+					CreatedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+					UpdatedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+					Name:      sql.NullString{String: name, Valid: true},
+					Url:       sql.NullString{String: sub.URL, Valid: true},
+					UserID:    user.ID,
+				})
+			}
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s: %v", sub.URL, err))
+				continue
+			}
+			created++
+		} else if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", sub.URL, err))
+			continue
+		}
+
+		if !followed[feed.ID] {
+			_, err = s.Db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+				ID:        uuid.New(),
+				CreatedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+				UpdatedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+				UserID:    user.ID,
+				FeedID:    feed.ID,
+			})
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s: failed to follow: %v", sub.URL, err))
+				continue
+			}
+			followed[feed.ID] = true
+			followedCount++
+		}
+
+		if sub.Category != "" {
+			err = s.Db.SetFeedFollowCategory(ctx, database.SetFeedFollowCategoryParams{
+				Category: sql.NullString{String: sub.Category, Valid: true},
+				UserID:   user.ID,
+				FeedID:   feed.ID,
+			})
+			if err != nil {
+				cmd.notef("Failed to set category for '%s': %v\n", sub.URL, err)
+			}
+		}
+	}
+
+	cmd.notef("Imported %v of %v feeds: %v created, %v newly followed\n", len(subs)-len(skipped), len(subs), created, followedCount)
+	if len(skipped) > 0 {
+		cmd.notef("Skipped %v feeds:\n", len(skipped))
+		for _, reason := range skipped {
+			cmd.notef("  %s\n", strings.TrimSpace(reason))
+		}
+	}
+	return nil
+}
+
+// uniqueFeedName returns name, or the URL when there is none, made unique
+// among the feed names: a name already taken gets the host of url added,
+// then a number.
+func uniqueFeedName(ctx context.Context, s *config.State, name, feedURL string) (string, error) {
+	if name == "" {
+		name = feedURL
+	}
+	candidates := []string{name}
+	if parsed, err := url.Parse(feedURL); err == nil && parsed.Host != "" {
+		candidates = append(candidates, fmt.Sprintf("%s (%s)", name, parsed.Host))
+	}
+	for i := 2; i <= 100; i++ {
+		candidates = append(candidates, fmt.Sprintf("%s (%d)", name, i))
+	}
+
+	for _, candidate := range candidates {
+		_, err := s.Db.GetFeedByName(ctx, sql.NullString{String: candidate, Valid: true})
+		if err == sql.ErrNoRows {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("Failed to check feed name:\n%v\n", err)
+		}
+	}
+	return "", fmt.Errorf("feed name '%s' is taken", name)
+}
+
+func HandlerExportOPML(ctx context.Context, s *config.State, cmd Command, user database.User) error {
+	following, err := s.Db.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve follows for user id:\n%v\n", err)
+	}
+
+	subs := []opml.Subscription{}
+	for _, follow := range following {
+		subs = append(subs, opml.Subscription{
+			Name:     follow.FeedName.String,
+			URL:      follow.FeedUrl.String,
+			Category: follow.Category.String,
+		})
+	}
+
+	out := os.Stdout
+	if len(cmd.Args) >= 1 {
+		out, err = os.Create(cmd.Args[0])
+		if err != nil {
+			return fmt.Errorf("Failed to create OPML file:\n%v\n", err)
+		}
+		defer out.Close()
+	}
+
+	err = opml.Write(out, user.Name, subs)
+	if err != nil {
+		return err
+	}
+
+	if out != os.Stdout {
+		// Close flushes the file, a failure means the export is incomplete
+		err = out.Close()
+		if err != nil {
+			return fmt.Errorf("Failed to write OPML file:\n%v\n", err)
+		}
+		cmd.notef("Exported %v feeds to %v\n", len(subs), cmd.Args[0])
+	}
+	return nil
+}
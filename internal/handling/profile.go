@@ -0,0 +1,74 @@
+package handling
+
+import (
+	"context"
+	"strings"
+
+	"github.com/wfcornelissen/blogag/internal/config"
+)
+
+var profileActions = []string{"list", "use", "add", "remove"}
+
+// HandlerProfile manages the named profiles in the config file.
+func HandlerProfile(ctx context.Context, s *config.State, cmd Command) error {
+	cfg := s.State
+	action := cmd.Args[0]
+	if action == "list" {
+		if len(cmd.Args) > 1 {
+			return usageErrorf(cmd, "Too many arguments. Usage: gator profile list")
+		}
+		views := []ProfileView{}
+		for _, name := range cfg.ProfileNames() {
+			profile, _ := cfg.LookupProfile(name)
+			views = append(views, ProfileView{
+				Name:    name,
+				DbUrl:   redactURL(profile.DbUrl),
+				User:    profile.CurrentUserName,
+				Current: name == cfg.Profile(),
+			})
+		}
+		return render(cmd, views)
+	}
+
+	if len(cmd.Args) < 2 {
+		return usageErrorf(cmd, "Usage: gator profile %v <name>", action)
+	}
+	name := cmd.Args[1]
+	if action != "add" && len(cmd.Args) > 2 {
+		return usageErrorf(cmd, "Too many arguments. Usage: gator profile %v <name>", action)
+	}
+
+	switch action {
+	case "use":
+		err := cfg.UseProfile(name)
+		if err != nil {
+			return err
+		}
+		cmd.notef("Switched to profile %v\n", name)
+		if cfg.Profile() != name {
+			cmd.notef("Note: %v is picked with --profile or %v, which overrides this\n", cfg.Profile(), config.ProfileEnv)
+		}
+
+	case "add":
+		profile := config.Profile{}
+		if len(cmd.Args) > 2 {
+			profile.DbUrl = cmd.Args[2]
+		}
+		err := cfg.AddProfile(name, profile)
+		if err != nil {
+			return err
+		}
+		cmd.notef("Added profile %v, switch to it with 'gator profile use %v'\n", name, name)
+
+	case "remove":
+		err := cfg.RemoveProfile(name)
+		if err != nil {
+			return err
+		}
+		cmd.notef("Removed profile %v\n", name)
+
+	default:
+		return usageErrorf(cmd, "Unknown action '%s'. Expected %v", action, strings.Join(profileActions, ", "))
+	}
+	return nil
+}
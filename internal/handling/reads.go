@@ -0,0 +1,169 @@
+package handling
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/config"
+	"github.com/wfcornelissen/blogag/internal/database"
+)
+
+func HandlerRead(ctx context.Context, s *config.State, cmd Command, user database.User) error {
+	return markReadState(ctx, s, cmd, user, true)
+}
+
+func HandlerUnread(ctx context.Context, s *config.State, cmd Command, user database.User) error {
+	return markReadState(ctx, s, cmd, user, false)
+}
+
+// markReadState marks a single post, every post of a feed, or every followed
+// post older than a cutoff as read or unread.
+func markReadState(ctx context.Context, s *config.State, cmd Command, user database.User, read bool) error {
+	feedRef, olderThan := cmd.stringFlag("feed"), cmd.stringFlag("older-than")
+	targets := len(cmd.Args)
+	if feedRef != "" {
+		targets++
+	}
+	if olderThan != "" {
+		targets++
+	}
+	if targets != 1 {
+		return usageErrorf(cmd, "Expected exactly one of a post ID or URL, -feed or -older-than")
+	}
+
+	state := "unread"
+	if read {
+		state = "read"
+	}
+
+	switch {
+	case feedRef != "":
+		feed, err := findFeed(ctx, s, feedRef)
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		feedID := uuid.NullUUID{UUID: feed.ID, Valid: true}
+		if read {
+			count, err = s.Db.MarkFeedRead(ctx, database.MarkFeedReadParams{
+				UserID: user.ID,
+				ReadAt: time.Now().UTC(),
+				FeedID: feedID,
+			})
+		} else {
+			count, err = s.Db.MarkFeedUnread(ctx, database.MarkFeedUnreadParams{
+				UserID: user.ID,
+				FeedID: feedID,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to mark feed as %s:\n%v\n", state, err)
+		}
+		cmd.notef("Marked %v posts from '%v' as %s\n", count, feed.Name.String, state)
+
+	case olderThan != "":
+		before, err := parseTimeArg(olderThan)
+		if err != nil {
+			return usageErrorf(cmd, "%v", err)
+		}
+
+		var count int64
+		if read {
+			count, err = s.Db.MarkPostsReadBefore(ctx, database.MarkPostsReadBeforeParams{
+				ReadAt: time.Now().UTC(),
+				UserID: user.ID,
+				Before: before,
+			})
+		} else {
+			count, err = s.Db.MarkPostsUnreadBefore(ctx, database.MarkPostsUnreadBeforeParams{
+				UserID: user.ID,
+				Before: before,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to mark posts as %s:\n%v\n", state, err)
+		}
+		cmd.notef("Marked %v posts older than %v as %s\n", count, before.Local().Format("Mon, 02 Jan 2006 15:04"), state)
+
+	default:
+		post, err := findPost(ctx, s, cmd.Args[0])
+		if err != nil {
+			return err
+		}
+
+		if read {
+			err = s.Db.MarkPostRead(ctx, database.MarkPostReadParams{
+				UserID: user.ID,
+				PostID: post.ID,
+				ReadAt: time.Now().UTC(),
+			})
+		} else {
+			err = s.Db.MarkPostUnread(ctx, database.MarkPostUnreadParams{
+				UserID: user.ID,
+				PostID: post.ID,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to mark post as %s:\n%v\n", state, err)
+		}
+		cmd.notef("Marked '%v' as %s\n", post.Title, state)
+	}
+
+	return nil
+}
+
+// findPost looks a post up by the ID shown in browse, or by its URL.
+func findPost(ctx context.Context, s *config.State, ref string) (database.GetPostByIDRow, error) {
+	var post database.GetPostByIDRow
+	var err error
+	if id, parseErr := uuid.Parse(ref); parseErr == nil {
+		post, err = s.Db.GetPostByID(ctx, id)
+	} else {
+		var byURL database.GetPostByURLRow
+		byURL, err = s.Db.GetPostByURL(ctx, ref)
+		post = database.GetPostByIDRow(byURL)
+	}
+	if err == sql.ErrNoRows {
+		return database.GetPostByIDRow{}, fmt.Errorf("post '%s' doesnt exist", ref)
+	}
+	if err != nil {
+		return database.GetPostByIDRow{}, fmt.Errorf("Failed to fetch post:\n%v\n", err)
+	}
+	return post, nil
+}
+
+// parseTimeArg accepts an age such as "36h" or "7d", a date such as
+// "2024-01-31" or an RFC 3339 time, and returns the point in time it refers
+// to. It is in UTC, like the post times it is compared with.
+func parseTimeArg(value string) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil {
+			return time.Now().AddDate(0, 0, -n).UTC(), nil
+		}
+	}
+
+	age, err := time.ParseDuration(value)
+	if err == nil {
+		return time.Now().Add(-age).UTC(), nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err == nil {
+		return date.UTC(), nil
+	}
+
+	// browse prints the times it resolved as RFC 3339 in its next page hint
+	at, err := time.Parse(time.RFC3339Nano, value)
+	if err == nil {
+		return at.UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("Invalid age '%s'. Expected a duration like 36h or 7d, or a date like 2024-01-31", value)
+}
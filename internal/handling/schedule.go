@@ -0,0 +1,124 @@
+package handling
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/database"
+	"github.com/wfcornelissen/blogag/internal/rss"
+)
+
+const (
+	// maxPollInterval caps how long a quiet feed can go unchecked
+	maxPollInterval = 24 * time.Hour
+	// claimLease is how long a claimed feed stays reserved, so a crashed
+	// agg process doesn't keep it from being fetched forever
+	claimLease = 10 * time.Minute
+	// recentPostSample is how many posts the posting frequency is derived from
+	recentPostSample = 10
+	// addFeedInterval stands in for the agg interval when addfeed schedules
+	// the first fetch after its own
+	addFeedInterval = 15 * time.Minute
+)
+
+// scheduleHints are the publisher's polling hints as stored on the feed row.
+type scheduleHints struct {
+	minInterval time.Duration
+	skipHours   int32
+	skipDays    int32
+}
+
+func hintsFromFeed(feed *rss.RSSFeed) scheduleHints {
+	return scheduleHints{
+		minInterval: feed.MinInterval(),
+		skipHours:   feed.SkipHourMask(),
+		skipDays:    feed.SkipDayMask(),
+	}
+}
+
+func hintsFromRow(feed database.Feed) scheduleHints {
+	return scheduleHints{
+		minInterval: time.Duration(feed.MinFetchInterval.Int32) * time.Second,
+		skipHours:   feed.SkipHours,
+		skipDays:    feed.SkipDays,
+	}
+}
+
+func storeScheduleHints(ctx context.Context, db database.Querier, feedID uuid.UUID, hints scheduleHints) error {
+	return db.UpdateFeedScheduleHints(ctx, database.UpdateFeedScheduleHintsParams{
+		MinFetchInterval: sql.NullInt32{
+			Int32: int32(hints.minInterval / time.Second),
+			Valid: hints.minInterval > 0},
+		SkipHours: hints.skipHours,
+		SkipDays:  hints.skipDays,
+		ID:        feedID,
+	})
+}
+
+// nextFetchAt works out when a feed should be polled again. The interval
+// follows the median gap between its recent posts, bounded by the agg
+// interval and maxPollInterval, never shorter than the publisher asks for,
+// and doubled for every consecutive failure.
+func nextFetchAt(now time.Time, base time.Duration, postDates []time.Time, hints scheduleHints, failures int32) time.Time {
+	interval := min(max(medianGap(postDates), base), maxPollInterval)
+	interval = max(interval, hints.minInterval)
+
+	if failures > 0 {
+		backoff := base
+		for i := int32(0); i < failures && backoff < maxPollInterval; i++ {
+			backoff *= 2
+		}
+		interval = max(interval, min(backoff, maxPollInterval))
+	}
+
+	next := now.Add(interval)
+	// skipHours and skipDays are in GMT; a week of hours covers every mask
+	for i := 0; i < 7*24 && skipped(next.UTC(), hints); i++ {
+		next = next.UTC().Truncate(time.Hour).Add(time.Hour)
+	}
+	return next
+}
+
+func skipped(t time.Time, hints scheduleHints) bool {
+	return hints.skipHours&(1<<t.Hour()) != 0 || hints.skipDays&(1<<t.Weekday()) != 0
+}
+
+// medianGap returns the median time between consecutive posts, or 0 when
+// there are too few posts to tell.
+func medianGap(postDates []time.Time) time.Duration {
+	if len(postDates) < 2 {
+		return 0
+	}
+
+	sorted := append([]time.Time(nil), postDates...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].After(sorted[j]) })
+
+	gaps := make([]time.Duration, 0, len(sorted)-1)
+	for i := 1; i < len(sorted); i++ {
+		gaps = append(gaps, sorted[i-1].Sub(sorted[i]))
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+
+	return gaps[len(gaps)/2]
+}
+
+func recentPostDates(ctx context.Context, db database.Querier, feedID uuid.UUID) ([]time.Time, error) {
+	rows, err := db.GetRecentPostDates(ctx, database.GetRecentPostDatesParams{
+		FeedID: uuid.NullUUID{UUID: feedID, Valid: true},
+		Limit:  recentPostSample,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dates := []time.Time{}
+	for _, row := range rows {
+		if row.Valid {
+			dates = append(dates, row.Time)
+		}
+	}
+	return dates, nil
+}
@@ -0,0 +1,70 @@
+package handling
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMedianGap(t *testing.T) {
+	at := func(hours ...int) []time.Time {
+		dates := []time.Time{}
+		for _, h := range hours {
+			dates = append(dates, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(h)*time.Hour))
+		}
+		return dates
+	}
+	tests := []struct {
+		name  string
+		dates []time.Time
+		want  time.Duration
+	}{
+		{"no posts", nil, 0},
+		{"one post", at(5), 0},
+		{"odd number of gaps", at(0, 1, 3, 13), 2 * time.Hour},
+		{"even number of gaps", at(0, 1, 4), 3 * time.Hour},
+		{"out of order", at(13, 0, 3, 1), 2 * time.Hour},
+	}
+	for _, test := range tests {
+		if got := medianGap(test.dates); got != test.want {
+			t.Errorf("%v: medianGap = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNextFetchAt(t *testing.T) {
+	// A Monday, 10:00 GMT
+	now := time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+	base := 15 * time.Minute
+	every := func(gap time.Duration) []time.Time {
+		return []time.Time{now.Add(-3 * gap), now.Add(-2 * gap), now.Add(-gap)}
+	}
+	tests := []struct {
+		name     string
+		posts    []time.Time
+		hints    scheduleHints
+		failures int32
+		want     time.Time
+	}{
+		{"no posts", nil, scheduleHints{}, 0, now.Add(base)},
+		{"median post gap", every(time.Hour), scheduleHints{}, 0, now.Add(time.Hour)},
+		{"gap below the agg interval", every(5 * time.Minute), scheduleHints{}, 0, now.Add(base)},
+		{"gap above the cap", every(72 * time.Hour), scheduleHints{}, 0, now.Add(maxPollInterval)},
+		{"ttl above the gap", every(time.Hour), scheduleHints{minInterval: 3 * time.Hour}, 0, now.Add(3 * time.Hour)},
+		{"ttl below the gap", every(time.Hour), scheduleHints{minInterval: 30 * time.Minute}, 0, now.Add(time.Hour)},
+		{"ttl above the cap", nil, scheduleHints{minInterval: 48 * time.Hour}, 0, now.Add(48 * time.Hour)},
+		{"one failure", nil, scheduleHints{}, 1, now.Add(2 * base)},
+		{"three failures", nil, scheduleHints{}, 3, now.Add(8 * base)},
+		{"backoff below the gap", every(time.Hour), scheduleHints{}, 1, now.Add(time.Hour)},
+		{"backoff cap", nil, scheduleHints{}, 20, now.Add(maxPollInterval)},
+		{"skipped hours", nil, scheduleHints{skipHours: 1<<10 | 1<<11}, 0, now.Add(2 * time.Hour)},
+		{"skipped day", nil, scheduleHints{skipDays: 1 << time.Monday}, 0, now.Add(14 * time.Hour)},
+		{"skipped day and hour", nil, scheduleHints{skipHours: 1 << 0, skipDays: 1 << time.Monday}, 0, now.Add(15 * time.Hour)},
+		{"every hour skipped", nil, scheduleHints{skipHours: 1<<24 - 1}, 0, now.Add(base).Truncate(time.Hour).Add(7 * 24 * time.Hour)},
+	}
+	for _, test := range tests {
+		got := nextFetchAt(now, base, test.posts, test.hints, test.failures)
+		if !got.Equal(test.want) {
+			t.Errorf("%v: nextFetchAt = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
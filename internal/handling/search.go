@@ -0,0 +1,41 @@
+package handling
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wfcornelissen/blogag/internal/config"
+	"github.com/wfcornelissen/blogag/internal/database"
+)
+
+const defaultSearchLimit = 10
+
+// HandlerSearch runs a full-text search over posts. The query supports the
+// websearch syntax: "quoted phrases", OR, and -excluded words.
+func HandlerSearch(ctx context.Context, s *config.State, cmd Command, user database.User) error {
+	limit := cmd.intFlag("limit")
+	if limit < 1 {
+		return usageErrorf(cmd, "Invalid limit %v", limit)
+	}
+
+	results, err := s.Db.SearchPosts(ctx, database.SearchPostsParams{
+		Query:      strings.Join(cmd.Args, " "),
+		AllFeeds:   cmd.boolFlag("all"),
+		UserID:     user.ID,
+		MaxResults: int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to search posts:\n%v\n", err)
+	}
+
+	if len(results) == 0 {
+		cmd.notef("No posts found\n")
+	}
+
+	views := []SearchResultView{}
+	for _, result := range results {
+		views = append(views, newSearchResultView(result))
+	}
+	return render(cmd, views)
+}
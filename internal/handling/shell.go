@@ -0,0 +1,272 @@
+package handling
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/wfcornelissen/blogag/internal/config"
+	"golang.org/x/term"
+)
+
+const (
+	shellHistoryFile = ".gator_history"
+	shellHistorySize = 1000
+)
+
+// HandlerShell reads commands line by line and runs them against the same
+// state and database pool, so a session pays for connecting only once.
+func (c *Commands) HandlerShell(ctx context.Context, s *config.State, cmd Command) error {
+	// Ctrl-C stops the running command rather than the shell, so commands
+	// get their own signal context instead of the one of this process
+	base := context.WithoutCancel(ctx)
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if c.runShellLine(base, s, scanner.Text()) {
+				return nil
+			}
+		}
+		return scanner.Err()
+	}
+
+	history, err := loadShellHistory()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: history disabled: %v\n", err)
+	}
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "")
+	if history != nil {
+		t.History = history
+	}
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		return c.completeShellLine(base, s, t, line, pos)
+	}
+
+	fmt.Println("Type 'help' for the commands, 'exit' or Ctrl-D to leave.")
+	for {
+		t.SetPrompt(shellPrompt(s))
+
+		// The terminal is only raw while editing, so commands print normally
+		oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+		if err != nil {
+			return fmt.Errorf("Failed to set up terminal:\n%v\n", err)
+		}
+		line, err := t.ReadLine()
+		term.Restore(int(os.Stdin.Fd()), oldState)
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to read command:\n%v\n", err)
+		}
+
+		if c.runShellLine(base, s, line) {
+			return nil
+		}
+	}
+}
+
+// runShellLine runs a single line and reports whether the shell should exit.
+func (c *Commands) runShellLine(ctx context.Context, s *config.State, line string) bool {
+	words, err := splitShellWords(line)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	if len(words) == 0 {
+		return false
+	}
+
+	switch words[0] {
+	case "exit", "quit":
+		return true
+	case ShellCommand.Name:
+		fmt.Fprintln(os.Stderr, "Already in a shell")
+		return false
+	}
+
+	cmdCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = c.Run(cmdCtx, s, Command{Name: words[0], Args: words[1:]})
+	if err != nil {
+		PrintError(os.Stderr, err)
+	}
+	return false
+}
+
+// completeShellLine completes the word under the cursor. A single match is
+// filled in, several are listed after filling in what they share.
+func (c *Commands) completeShellLine(ctx context.Context, s *config.State, w io.Writer, line string, pos int) (string, int, bool) {
+	before := line[:pos]
+	words := strings.Fields(before)
+	if len(words) == 0 || strings.HasSuffix(before, " ") {
+		words = append(words, "")
+	}
+	current := words[len(words)-1]
+
+	candidates := c.Complete(ctx, s, words)
+	if len(candidates) == 0 {
+		return "", 0, false
+	}
+
+	completion := candidates[0]
+	if len(candidates) > 1 {
+		completion = commonPrefix(candidates)
+		if completion == current {
+			fmt.Fprintln(w, strings.Join(candidates, "  "))
+			return "", 0, false
+		}
+	} else {
+		completion += " "
+	}
+
+	start := pos - len(current)
+	newLine := line[:start] + completion + line[pos:]
+	return newLine, start + len(completion), true
+}
+
+// shellPrompt shows the current user, and the profile when it isn't the
+// default one.
+func shellPrompt(s *config.State) string {
+	if s.State == nil {
+		return "gator> "
+	}
+	who := s.State.CurrentUserName
+	if profile := s.State.Profile(); profile != config.DefaultProfile {
+		who = profile + ":" + who
+	}
+	if who == "" {
+		return "gator> "
+	}
+	return fmt.Sprintf("gator (%v)> ", who)
+}
+
+// splitShellWords splits a line into words like a shell would, honouring
+// single and double quotes and backslash escapes.
+func splitShellWords(line string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord, escaped := false, false
+	var quote rune
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errors.New("Unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, value := range values[1:] {
+		for !strings.HasPrefix(value, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// shellHistory is the line history of the shell, kept in a file in the
+// home directory so it survives between sessions.
+type shellHistory struct {
+	path    string
+	entries []string
+}
+
+func loadShellHistory() (*shellHistory, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	h := &shellHistory{path: filepath.Join(homeDir, shellHistoryFile)}
+	data, err := os.ReadFile(h.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if len(h.entries) > shellHistorySize {
+		h.entries = h.entries[len(h.entries)-shellHistorySize:]
+	}
+	return h, nil
+}
+
+func (h *shellHistory) Add(entry string) {
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > shellHistorySize {
+		h.entries = h.entries[1:]
+	}
+
+	// History is a convenience, failing to save it shouldn't stop the shell
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, entry)
+}
+
+func (h *shellHistory) Len() int {
+	return len(h.entries)
+}
+
+// At returns the entry idx lines back, 0 being the most recent.
+func (h *shellHistory) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
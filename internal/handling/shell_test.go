@@ -0,0 +1,44 @@
+package handling
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+		ok   bool
+	}{
+		{``, []string{}, true},
+		{" \t ", []string{}, true},
+		{`browse 5`, []string{"browse", "5"}, true},
+		{"  browse\t 5  ", []string{"browse", "5"}, true},
+		{`addfeed "My Blog" https://blog.example`, []string{"addfeed", "My Blog", "https://blog.example"}, true},
+		{`star 'it "works"'`, []string{"star", `it "works"`}, true},
+		{`star "it's"`, []string{"star", "it's"}, true},
+		{`a"b c"d`, []string{"ab cd"}, true},
+		{`""`, []string{""}, true},
+		{`a '' b`, []string{"a", "", "b"}, true},
+		{`My\ Blog`, []string{"My Blog"}, true},
+		{`a\\b`, []string{`a\b`}, true},
+		{`"say \"hi\""`, []string{`say "hi"`}, true},
+		{`'back\slash'`, []string{`back\slash`}, true},
+		{`\"`, []string{`"`}, true},
+		{`"open`, nil, false},
+		{`'open`, nil, false},
+		{`"a\"`, nil, false},
+		{`trailing\`, nil, false},
+	}
+	for _, test := range tests {
+		words, err := splitShellWords(test.line)
+		if (err == nil) != test.ok {
+			t.Errorf("splitShellWords(%q) err = %v, want ok %v", test.line, err, test.ok)
+			continue
+		}
+		if test.ok && !reflect.DeepEqual(words, test.want) {
+			t.Errorf("splitShellWords(%q) = %q, want %q", test.line, words, test.want)
+		}
+	}
+}
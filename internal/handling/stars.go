@@ -0,0 +1,75 @@
+package handling
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/config"
+	"github.com/wfcornelissen/blogag/internal/database"
+)
+
+func HandlerStar(ctx context.Context, s *config.State, cmd Command, user database.User) error {
+	post, err := findPost(ctx, s, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+
+	// Starring again replaces the note
+	note := strings.Join(cmd.Args[1:], " ")
+	star, err := s.Db.CreateStar(ctx, database.CreateStarParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Note:      nullString(note),
+		PostID:    post.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to star post:\n%v\n", err)
+	}
+
+	cmd.notef("Starred '%v'\n", star.Title)
+	return nil
+}
+
+func HandlerUnstar(ctx context.Context, s *config.State, cmd Command, user database.User) error {
+	// Starred posts may outlive the post itself, so a URL is used as is
+	url := cmd.Args[0]
+	if _, err := uuid.Parse(url); err == nil {
+		post, err := findPost(ctx, s, url)
+		if err != nil {
+			return err
+		}
+		url = post.Url
+	}
+
+	count, err := s.Db.DeleteStar(ctx, database.DeleteStarParams{
+		UserID: user.ID,
+		Url:    url,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to unstar post:\n%v\n", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("post '%s' isnt starred", cmd.Args[0])
+	}
+
+	cmd.notef("Unstarred post\n")
+	return nil
+}
+
+func HandlerStarred(ctx context.Context, s *config.State, cmd Command, user database.User) error {
+	stars, err := s.Db.GetStarsForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to fetch starred posts:\n%v\n", err)
+	}
+
+	views := []StarView{}
+	for _, star := range stars {
+		views = append(views, newStarView(star))
+	}
+	return render(cmd, views)
+}
@@ -0,0 +1,421 @@
+package handling
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wfcornelissen/blogag/internal/database"
+	"github.com/wfcornelissen/blogag/internal/migrate"
+)
+
+// View models are what commands print. They are rendered by the output
+// package in the format picked with --output.
+
+const displayTime = "Mon, 02 Jan 2006 15:04"
+
+type UserView struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+}
+
+func (v UserView) Columns() []string { return []string{"name", "current"} }
+
+func (v UserView) Values() []string {
+	return []string{v.Name, strconv.FormatBool(v.Current)}
+}
+
+func (v UserView) Text() string {
+	if v.Current {
+		return fmt.Sprintf(" * %v (current)\n", v.Name)
+	}
+	return fmt.Sprintf(" * %v\n", v.Name)
+}
+
+type FeedView struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	User string `json:"user"`
+}
+
+func (v FeedView) Columns() []string { return []string{"name", "url", "user"} }
+
+func (v FeedView) Values() []string {
+	return []string{v.Name, v.URL, v.User}
+}
+
+func (v FeedView) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Name:	%v\n", v.Name)
+	fmt.Fprintf(&b, "URL:	%v\n", v.URL)
+	fmt.Fprintf(&b, "User:	%v\n", v.User)
+	b.WriteString("--- END OF FEED ---\n")
+	return b.String()
+}
+
+type FollowView struct {
+	Feed     string `json:"feed"`
+	URL      string `json:"url"`
+	Category string `json:"category,omitempty"`
+	Unread   int64  `json:"unread"`
+}
+
+func newFollowView(row database.GetFeedFollowsForUserRow) FollowView {
+	return FollowView{
+		Feed:     row.FeedName.String,
+		URL:      row.FeedUrl.String,
+		Category: row.Category.String,
+		Unread:   row.UnreadCount,
+	}
+}
+
+func (v FollowView) Columns() []string { return []string{"feed", "url", "category", "unread"} }
+
+func (v FollowView) Values() []string {
+	return []string{v.Feed, v.URL, v.Category, strconv.FormatInt(v.Unread, 10)}
+}
+
+func (v FollowView) Text() string {
+	return fmt.Sprintf("Feed name: %v (%v unread)\n", v.Feed, v.Unread)
+}
+
+type FeedStatusView struct {
+	Name     string     `json:"name"`
+	URL      string     `json:"url"`
+	Failures int32      `json:"failures"`
+	Status   *int32     `json:"status,omitempty"`
+	FailedAt *time.Time `json:"failed_at,omitempty"`
+	Error    string     `json:"error"`
+}
+
+func newFeedStatusView(feed database.GetFailingFeedsRow) FeedStatusView {
+	v := FeedStatusView{
+		Name:     feed.Name.String,
+		URL:      feed.Url.String,
+		Failures: feed.ConsecutiveFailures,
+		FailedAt: timePtr(feed.LastErrorAt),
+		Error:    feed.LastError.String,
+	}
+	if feed.LastErrorStatus.Valid {
+		v.Status = &feed.LastErrorStatus.Int32
+	}
+	return v
+}
+
+func (v FeedStatusView) Columns() []string {
+	return []string{"name", "url", "failures", "status", "failed_at", "error"}
+}
+
+func (v FeedStatusView) Values() []string {
+	status := ""
+	if v.Status != nil {
+		status = strconv.Itoa(int(*v.Status))
+	}
+	return []string{v.Name, v.URL, strconv.Itoa(int(v.Failures)), status, formatTime(v.FailedAt), v.Error}
+}
+
+func (v FeedStatusView) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Name:		%v\n", v.Name)
+	fmt.Fprintf(&b, "URL:		%v\n", v.URL)
+	fmt.Fprintf(&b, "Failures:	%v in a row\n", v.Failures)
+	if v.Status != nil {
+		fmt.Fprintf(&b, "Status:		%v\n", *v.Status)
+	}
+	if v.FailedAt != nil {
+		fmt.Fprintf(&b, "Failed at:	%v\n", v.FailedAt.Format(displayTime))
+	}
+	fmt.Fprintf(&b, "Error:		%v\n", v.Error)
+	b.WriteString("--- END OF FEED ---\n")
+	return b.String()
+}
+
+type PostView struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	// Cursor is passed to browse --before to continue after this post
+	Cursor string `json:"cursor"`
+}
+
+func newPostView(post database.BrowsePostsRow) PostView {
+	return PostView{
+		ID:          post.ID.String(),
+		Title:       post.Title,
+		URL:         post.Url,
+		Description: post.Description.String,
+		PublishedAt: timePtr(post.PublishedAt),
+		Cursor:      encodeCursor(post),
+	}
+}
+
+func (v PostView) Columns() []string {
+	return []string{"id", "title", "url", "published_at", "cursor"}
+}
+
+func (v PostView) Values() []string {
+	return []string{v.ID, v.Title, v.URL, formatTime(v.PublishedAt), v.Cursor}
+}
+
+func (v PostView) Framed() bool { return true }
+
+func (v PostView) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📰 %s\n", v.Title)
+	fmt.Fprintf(&b, "🔗 %s\n", v.URL)
+	if v.Description != "" {
+		fmt.Fprintf(&b, "📝 %s\n", v.Description)
+	}
+	if v.PublishedAt != nil {
+		fmt.Fprintf(&b, "📅 %s\n", v.PublishedAt.Format(displayTime))
+	}
+	fmt.Fprintf(&b, "🆔 %s\n", v.ID)
+	return b.String()
+}
+
+type StarView struct {
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Feed        string     `json:"feed,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	StarredAt   time.Time  `json:"starred_at"`
+	// Content is only in the json output, it is too long for a table
+	Content string `json:"content,omitempty"`
+}
+
+func newStarView(star database.Star) StarView {
+	return StarView{
+		Title:       star.Title,
+		URL:         star.Url,
+		Feed:        star.FeedName.String,
+		PublishedAt: timePtr(star.PublishedAt),
+		Note:        star.Note.String,
+		StarredAt:   star.CreatedAt,
+		Content:     star.Content.String,
+	}
+}
+
+func (v StarView) Columns() []string {
+	return []string{"title", "url", "feed", "published_at", "note", "starred_at"}
+}
+
+func (v StarView) Values() []string {
+	return []string{v.Title, v.URL, v.Feed, formatTime(v.PublishedAt), v.Note, formatTime(&v.StarredAt)}
+}
+
+func (v StarView) Framed() bool { return true }
+
+func (v StarView) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "⭐ %s\n", v.Title)
+	fmt.Fprintf(&b, "🔗 %s\n", v.URL)
+	if v.Feed != "" {
+		fmt.Fprintf(&b, "📡 %s\n", v.Feed)
+	}
+	if v.PublishedAt != nil {
+		fmt.Fprintf(&b, "📅 %s\n", v.PublishedAt.Format(displayTime))
+	}
+	if v.Note != "" {
+		fmt.Fprintf(&b, "🗒️ %s\n", v.Note)
+	}
+	return b.String()
+}
+
+type SearchResultView struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Feed        string     `json:"feed,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Rank        float32    `json:"rank"`
+	Headline    string     `json:"headline"`
+}
+
+func newSearchResultView(result database.SearchPostsRow) SearchResultView {
+	return SearchResultView{
+		ID:          result.ID.String(),
+		Title:       result.Title,
+		URL:         result.Url,
+		Feed:        result.FeedName.String,
+		PublishedAt: timePtr(result.PublishedAt),
+		Rank:        result.Rank,
+		Headline:    strings.Join(strings.Fields(result.Headline), " "),
+	}
+}
+
+func (v SearchResultView) Columns() []string {
+	return []string{"id", "title", "url", "feed", "published_at", "rank", "headline"}
+}
+
+func (v SearchResultView) Values() []string {
+	return []string{
+		v.ID, v.Title, v.URL, v.Feed, formatTime(v.PublishedAt),
+		strconv.FormatFloat(float64(v.Rank), 'f', 4, 32), v.Headline,
+	}
+}
+
+func (v SearchResultView) Framed() bool { return true }
+
+func (v SearchResultView) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📰 %s\n", v.Title)
+	fmt.Fprintf(&b, "🔗 %s\n", v.URL)
+	if v.Feed != "" {
+		fmt.Fprintf(&b, "📡 %s\n", v.Feed)
+	}
+	if v.PublishedAt != nil {
+		fmt.Fprintf(&b, "📅 %s\n", v.PublishedAt.Format(displayTime))
+	}
+	fmt.Fprintf(&b, "🔍 %s\n", v.Headline)
+	fmt.Fprintf(&b, "🆔 %s\n", v.ID)
+	return b.String()
+}
+
+// FetchView is the outcome of fetching one feed during agg.
+type FetchView struct {
+	Feed     string `json:"feed"`
+	NewPosts int    `json:"new_posts"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (v FetchView) Columns() []string { return []string{"feed", "new_posts", "error"} }
+
+func (v FetchView) Values() []string {
+	return []string{v.Feed, strconv.Itoa(v.NewPosts), v.Error}
+}
+
+func (v FetchView) Text() string {
+	if v.Error != "" {
+		return fmt.Sprintf("Failed to scrape '%s': %v\n", v.Feed, v.Error)
+	}
+	return fmt.Sprintf("Fetched '%s': %v new posts\n", v.Feed, v.NewPosts)
+}
+
+// AggSummaryView totals what agg did before it was stopped.
+type AggSummaryView struct {
+	Fetched  int64 `json:"fetched"`
+	Failed   int64 `json:"failed"`
+	NewPosts int64 `json:"new_posts"`
+}
+
+func (v AggSummaryView) Columns() []string { return []string{"fetched", "failed", "new_posts"} }
+
+func (v AggSummaryView) Values() []string {
+	return []string{
+		strconv.FormatInt(v.Fetched, 10),
+		strconv.FormatInt(v.Failed, 10),
+		strconv.FormatInt(v.NewPosts, 10),
+	}
+}
+
+func (v AggSummaryView) Text() string {
+	return fmt.Sprintf("Fetched %v feeds, %v failed, %v new posts\n", v.Fetched, v.Failed, v.NewPosts)
+}
+
+type MigrationView struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+func newMigrationView(status migrate.Status) MigrationView {
+	v := MigrationView{
+		Version: status.Version,
+		Name:    status.Name,
+		Applied: status.Applied,
+	}
+	if status.Applied && !status.AppliedAt.IsZero() {
+		v.AppliedAt = &status.AppliedAt
+	}
+	return v
+}
+
+func (v MigrationView) Columns() []string {
+	return []string{"version", "name", "applied", "applied_at"}
+}
+
+func (v MigrationView) Values() []string {
+	return []string{strconv.FormatInt(v.Version, 10), v.Name, strconv.FormatBool(v.Applied), formatTime(v.AppliedAt)}
+}
+
+func (v MigrationView) Text() string {
+	if !v.Applied {
+		return fmt.Sprintf("Pending                  %v\n", v.Name)
+	}
+	appliedAt := "unknown"
+	if v.AppliedAt != nil {
+		appliedAt = v.AppliedAt.Format(displayTime)
+	}
+	return fmt.Sprintf("%-24v %v\n", appliedAt, v.Name)
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// redactURL hides the password in a database URL, listings shouldn't show it.
+func redactURL(dbURL string) string {
+	parsed, err := url.Parse(dbURL)
+	if err != nil {
+		return dbURL
+	}
+	return parsed.Redacted()
+}
+
+type ConfigView struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+func (v ConfigView) Columns() []string { return []string{"key", "value", "source"} }
+
+func (v ConfigView) Values() []string {
+	return []string{v.Key, v.Value, v.Source}
+}
+
+func (v ConfigView) Text() string {
+	return fmt.Sprintf("%v = %v (%v)\n", v.Key, v.Value, v.Source)
+}
+
+type ProfileView struct {
+	Name    string `json:"name"`
+	DbUrl   string `json:"db_url"`
+	User    string `json:"user"`
+	Current bool   `json:"current"`
+}
+
+func (v ProfileView) Columns() []string { return []string{"name", "db_url", "user", "current"} }
+
+func (v ProfileView) Values() []string {
+	return []string{v.Name, v.DbUrl, v.User, strconv.FormatBool(v.Current)}
+}
+
+func (v ProfileView) Text() string {
+	marker := " "
+	if v.Current {
+		marker = "*"
+	}
+	user := v.User
+	if user == "" {
+		user = "not logged in"
+	}
+	return fmt.Sprintf(" %v %v (%v)\n", marker, v.Name, user)
+}
@@ -0,0 +1,256 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/database"
+)
+
+func (db *DB) ClaimFeedsToFetch(ctx context.Context, arg database.ClaimFeedsToFetchParams) ([]database.Feed, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	due := []int{}
+	for i, feed := range db.feeds {
+		if !feed.NextFetchAt.Valid || (arg.Now.Valid && !feed.NextFetchAt.Time.After(arg.Now.Time)) {
+			due = append(due, i)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b int) int {
+		return compareNullsFirst(db.feeds[a].NextFetchAt, db.feeds[b].NextFetchAt)
+	})
+	if limit := max(int(arg.BatchSize), 0); len(due) > limit {
+		due = due[:limit]
+	}
+
+	var items []database.Feed
+	for _, i := range due {
+		db.feeds[i].LastFetchedAt = arg.Now
+		db.feeds[i].NextFetchAt = arg.ClaimedUntil
+		items = append(items, db.feeds[i])
+	}
+	return items, nil
+}
+
+func (db *DB) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, feed := range db.feeds {
+		switch {
+		case feed.ID == arg.ID:
+			return database.Feed{}, uniqueViolation("feeds_pkey")
+		case nullStringEqual(feed.Name, arg.Name):
+			return database.Feed{}, uniqueViolation("feeds_name_key")
+		case nullStringEqual(feed.Url, arg.Url):
+			return database.Feed{}, uniqueViolation("feeds_url_key")
+		}
+	}
+	if _, exists := db.userByID(arg.UserID); !exists {
+		return database.Feed{}, foreignKeyViolation("feeds_user_id_fkey")
+	}
+
+	feed := database.Feed{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Name:        arg.Name,
+		Url:         arg.Url,
+		UserID:      arg.UserID,
+		Description: arg.Description,
+		SiteUrl:     arg.SiteUrl,
+		IconUrl:     arg.IconUrl,
+	}
+	db.feeds = append(db.feeds, feed)
+	return feed, nil
+}
+
+func (db *DB) GetAllFeeds(ctx context.Context) ([]database.GetAllFeedsRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var items []database.GetAllFeedsRow
+	for _, feed := range db.feeds {
+		items = append(items, database.GetAllFeedsRow{
+			Name:   feed.Name,
+			Url:    feed.Url,
+			UserID: feed.UserID,
+		})
+	}
+	return items, nil
+}
+
+func (db *DB) GetFailingFeeds(ctx context.Context) ([]database.GetFailingFeedsRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	failing := []database.Feed{}
+	for _, feed := range db.feeds {
+		if feed.ConsecutiveFailures > 0 {
+			failing = append(failing, feed)
+		}
+	}
+	// consecutive_failures DESC, name ASC with NULL names last
+	slices.SortStableFunc(failing, func(a, b database.Feed) int {
+		if c := cmp.Compare(b.ConsecutiveFailures, a.ConsecutiveFailures); c != 0 {
+			return c
+		}
+		switch {
+		case a.Name.Valid && b.Name.Valid:
+			return cmp.Compare(a.Name.String, b.Name.String)
+		case a.Name.Valid:
+			return -1
+		case b.Name.Valid:
+			return 1
+		}
+		return 0
+	})
+
+	var items []database.GetFailingFeedsRow
+	for _, feed := range failing {
+		items = append(items, database.GetFailingFeedsRow{
+			Name:                feed.Name,
+			Url:                 feed.Url,
+			LastFetchedAt:       feed.LastFetchedAt,
+			LastError:           feed.LastError,
+			LastErrorStatus:     feed.LastErrorStatus,
+			LastErrorAt:         feed.LastErrorAt,
+			ConsecutiveFailures: feed.ConsecutiveFailures,
+		})
+	}
+	return items, nil
+}
+
+func (db *DB) GetFeedByName(ctx context.Context, name sql.NullString) (database.Feed, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, feed := range db.feeds {
+		if nullStringEqual(feed.Name, name) {
+			return feed, nil
+		}
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+func (db *DB) GetFeedByURL(ctx context.Context, url sql.NullString) (database.Feed, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, feed := range db.feeds {
+		if nullStringEqual(feed.Url, url) {
+			return feed, nil
+		}
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+func (db *DB) GetNextFeedToFetch(ctx context.Context) (database.Feed, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if len(db.feeds) == 0 {
+		return database.Feed{}, sql.ErrNoRows
+	}
+	next := db.feeds[0]
+	for _, feed := range db.feeds[1:] {
+		if compareNullsFirst(feed.NextFetchAt, next.NextFetchAt) < 0 {
+			next = feed
+		}
+	}
+	return next, nil
+}
+
+func (db *DB) MarkFeedFetched(ctx context.Context, arg database.MarkFeedFetchedParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i := range db.feeds {
+		if nullStringEqual(db.feeds[i].Url, arg.Url) {
+			db.feeds[i].LastFetchedAt = arg.LastFetchedAt
+		}
+	}
+	return nil
+}
+
+func (db *DB) RecordFeedFailure(ctx context.Context, arg database.RecordFeedFailureParams) error {
+	db.updateFeed(arg.ID, func(feed *database.Feed) {
+		feed.LastError = arg.LastError
+		feed.LastErrorStatus = arg.LastErrorStatus
+		feed.LastErrorAt = arg.LastErrorAt
+		feed.ConsecutiveFailures++
+	})
+	return nil
+}
+
+func (db *DB) RecordFeedSuccess(ctx context.Context, id uuid.UUID) error {
+	db.updateFeed(id, func(feed *database.Feed) {
+		feed.LastError = sql.NullString{}
+		feed.LastErrorStatus = sql.NullInt32{}
+		feed.ConsecutiveFailures = 0
+	})
+	return nil
+}
+
+func (db *DB) SetFeedNextFetchAt(ctx context.Context, arg database.SetFeedNextFetchAtParams) error {
+	db.updateFeed(arg.ID, func(feed *database.Feed) {
+		feed.NextFetchAt = arg.NextFetchAt
+	})
+	return nil
+}
+
+func (db *DB) UpdateFeedCacheHeaders(ctx context.Context, arg database.UpdateFeedCacheHeadersParams) error {
+	db.updateFeed(arg.ID, func(feed *database.Feed) {
+		feed.Etag = arg.Etag
+		feed.LastModified = arg.LastModified
+	})
+	return nil
+}
+
+func (db *DB) UpdateFeedScheduleHints(ctx context.Context, arg database.UpdateFeedScheduleHintsParams) error {
+	db.updateFeed(arg.ID, func(feed *database.Feed) {
+		feed.MinFetchInterval = arg.MinFetchInterval
+		feed.SkipHours = arg.SkipHours
+		feed.SkipDays = arg.SkipDays
+	})
+	return nil
+}
+
+// updateFeed applies update to the feed with id, if there is one. Like an
+// UPDATE matching no rows, a missing feed is not an error.
+func (db *DB) updateFeed(id uuid.UUID, update func(feed *database.Feed)) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i := range db.feeds {
+		if db.feeds[i].ID == id {
+			update(&db.feeds[i])
+		}
+	}
+}
+
+func (db *DB) feedByID(id uuid.UUID) (database.Feed, bool) {
+	for _, feed := range db.feeds {
+		if feed.ID == id {
+			return feed, true
+		}
+	}
+	return database.Feed{}, false
+}
+
+// compareNullsFirst orders timestamps ascending, NULL before everything.
+func compareNullsFirst(a, b sql.NullTime) int {
+	switch {
+	case !a.Valid && !b.Valid:
+		return 0
+	case !a.Valid:
+		return -1
+	case !b.Valid:
+		return 1
+	}
+	return a.Time.Compare(b.Time)
+}
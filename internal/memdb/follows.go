@@ -0,0 +1,121 @@
+package memdb
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/database"
+)
+
+func (db *DB) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, follow := range db.follows {
+		if follow.ID == arg.ID {
+			return database.CreateFeedFollowRow{}, uniqueViolation("feed_follows_pkey")
+		}
+		if follow.UserID == arg.UserID && follow.FeedID == arg.FeedID {
+			return database.CreateFeedFollowRow{}, uniqueViolation("feed_follows_user_id_feed_id_key")
+		}
+	}
+	user, exists := db.userByID(arg.UserID)
+	if !exists {
+		return database.CreateFeedFollowRow{}, foreignKeyViolation("feed_follows_user_id_fkey")
+	}
+	feed, exists := db.feedByID(arg.FeedID)
+	if !exists {
+		return database.CreateFeedFollowRow{}, foreignKeyViolation("feed_follows_feed_id_fkey")
+	}
+
+	follow := database.FeedFollow{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+	}
+	db.follows = append(db.follows, follow)
+
+	return database.CreateFeedFollowRow{
+		ID:        follow.ID,
+		CreatedAt: follow.CreatedAt,
+		UpdatedAt: follow.UpdatedAt,
+		UserID:    follow.UserID,
+		FeedID:    follow.FeedID,
+		Category:  follow.Category,
+		FeedName:  feed.Name,
+		UserName:  user.Name,
+	}, nil
+}
+
+func (db *DB) DeleteFeedFollow(ctx context.Context, arg database.DeleteFeedFollowParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.follows = slices.DeleteFunc(db.follows, func(follow database.FeedFollow) bool {
+		return follow.UserID == arg.UserID && follow.FeedID == arg.FeedID
+	})
+	return nil
+}
+
+func (db *DB) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var items []database.GetFeedFollowsForUserRow
+	for _, follow := range db.follows {
+		if follow.UserID != userID {
+			continue
+		}
+		feed, _ := db.feedByID(follow.FeedID)
+		user, _ := db.userByID(follow.UserID)
+
+		unread := int64(0)
+		for _, post := range db.posts {
+			if post.FeedID.Valid && post.FeedID.UUID == follow.FeedID && !db.isRead(userID, post.ID) {
+				unread++
+			}
+		}
+
+		items = append(items, database.GetFeedFollowsForUserRow{
+			ID:          follow.ID,
+			CreatedAt:   follow.CreatedAt,
+			UpdatedAt:   follow.UpdatedAt,
+			UserID:      follow.UserID,
+			FeedID:      follow.FeedID,
+			Category:    follow.Category,
+			FeedName:    feed.Name,
+			FeedUrl:     feed.Url,
+			UserName:    user.Name,
+			UnreadCount: unread,
+		})
+	}
+	return items, nil
+}
+
+func (db *DB) SetFeedFollowCategory(ctx context.Context, arg database.SetFeedFollowCategoryParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i := range db.follows {
+		if db.follows[i].UserID == arg.UserID && db.follows[i].FeedID == arg.FeedID {
+			db.follows[i].Category = arg.Category
+		}
+	}
+	return nil
+}
+
+// isFollowing reports whether userID follows feedID.
+func (db *DB) isFollowing(userID uuid.UUID, feedID uuid.NullUUID) bool {
+	if !feedID.Valid {
+		return false
+	}
+	for _, follow := range db.follows {
+		if follow.UserID == userID && follow.FeedID == feedID.UUID {
+			return true
+		}
+	}
+	return false
+}
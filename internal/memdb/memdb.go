@@ -0,0 +1,77 @@
+// Package memdb is an in-memory implementation of database.Querier, so
+// handlers can be tested without a Postgres server. It follows the queries
+// in sql/queries closely enough for tests, not for production use.
+package memdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/database"
+)
+
+var _ database.Querier = (*DB)(nil)
+
+func New() *DB {
+	return &DB{reads: map[readKey]time.Time{}}
+}
+
+// ResetDatabase empties every table, like the TRUNCATE ... CASCADE it
+// replaces.
+func (db *DB) ResetDatabase(ctx context.Context) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.users = nil
+	db.feeds = nil
+	db.follows = nil
+	db.posts = nil
+	db.reads = map[readKey]time.Time{}
+	db.stars = nil
+	return nil
+}
+
+func uniqueViolation(constraint string) error {
+	return fmt.Errorf("%w \"%v\"", ErrUniqueViolation, constraint)
+}
+
+func foreignKeyViolation(constraint string) error {
+	return fmt.Errorf("%w \"%v\"", ErrForeignKeyViolation, constraint)
+}
+
+// nullStringEqual compares like SQL, NULL equals nothing.
+func nullStringEqual(a, b sql.NullString) bool {
+	return a.Valid && b.Valid && a.String == b.String
+}
+
+func compareUUID(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// postSortTime is COALESCE(published_at, created_at).
+func postSortTime(post database.Post) time.Time {
+	if post.PublishedAt.Valid {
+		return post.PublishedAt.Time
+	}
+	return post.CreatedAt
+}
+
+// comparePostOrder compares the browse order key of a post,
+// (published_at IS NOT NULL, COALESCE(published_at, created_at), id), with
+// the given one.
+func comparePostOrder(post database.Post, dated bool, sortTime time.Time, id uuid.UUID) int {
+	if post.PublishedAt.Valid != dated {
+		if dated {
+			return -1
+		}
+		return 1
+	}
+	if c := postSortTime(post).Compare(sortTime); c != 0 {
+		return c
+	}
+	return compareUUID(post.ID, id)
+}
@@ -0,0 +1,34 @@
+package memdb
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/database"
+)
+
+// DB keeps every table in memory. Rows are kept in insertion order, which
+// is also the order Postgres tends to return them in when a query doesn't
+// sort.
+type DB struct {
+	mu      sync.Mutex
+	users   []database.User
+	feeds   []database.Feed
+	follows []database.FeedFollow
+	posts   []database.Post
+	reads   map[readKey]time.Time
+	stars   []database.Star
+}
+
+type readKey struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+// Constraint errors, standing in for the ones Postgres raises.
+var (
+	ErrUniqueViolation     = errors.New("duplicate key value violates unique constraint")
+	ErrForeignKeyViolation = errors.New("insert or update violates foreign key constraint")
+)
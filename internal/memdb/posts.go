@@ -0,0 +1,321 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/database"
+)
+
+func (db *DB) BrowsePosts(ctx context.Context, arg database.BrowsePostsParams) ([]database.BrowsePostsRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	matches := []database.Post{}
+	for _, post := range db.posts {
+		if !db.isFollowing(arg.UserID, post.FeedID) {
+			continue
+		}
+		if arg.FeedID.Valid && post.FeedID.UUID != arg.FeedID.UUID {
+			continue
+		}
+		sortTime := postSortTime(post)
+		if arg.Since.Valid && sortTime.Before(arg.Since.Time) {
+			continue
+		}
+		if arg.Until.Valid && !sortTime.Before(arg.Until.Time) {
+			continue
+		}
+		// The row comparison (dated, sort time, id) < (before_dated, before_time, before_id)
+		if arg.BeforeTime.Valid && comparePostOrder(post, arg.BeforeDated.Bool, arg.BeforeTime.Time, arg.BeforeID.UUID) >= 0 {
+			continue
+		}
+		if arg.UnreadOnly && db.isRead(arg.UserID, post.ID) {
+			continue
+		}
+		matches = append(matches, post)
+	}
+
+	slices.SortFunc(matches, func(a, b database.Post) int {
+		return comparePostOrder(b, a.PublishedAt.Valid, postSortTime(a), a.ID)
+	})
+
+	var items []database.BrowsePostsRow
+	for _, post := range matches {
+		if len(items) >= int(arg.MaxPosts) {
+			break
+		}
+		items = append(items, database.BrowsePostsRow(postRow(post)))
+	}
+	return items, nil
+}
+
+func (db *DB) CreatePost(ctx context.Context, arg database.CreatePostParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, post := range db.posts {
+		if post.ID == arg.ID {
+			return 0, uniqueViolation("posts_pkey")
+		}
+		// ON CONFLICT (url) DO NOTHING
+		if post.Url == arg.Url {
+			return 0, nil
+		}
+	}
+	if arg.FeedID.Valid {
+		if _, exists := db.feedByID(arg.FeedID.UUID); !exists {
+			return 0, foreignKeyViolation("posts_feed_id_fkey")
+		}
+	}
+
+	db.posts = append(db.posts, database.Post{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Title:       arg.Title,
+		Url:         arg.Url,
+		Description: arg.Description,
+		PublishedAt: arg.PublishedAt,
+		FeedID:      arg.FeedID,
+		Content:     arg.Content,
+	})
+	return 1, nil
+}
+
+func (db *DB) GetPostByID(ctx context.Context, id uuid.UUID) (database.GetPostByIDRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	post, exists := db.postByID(id)
+	if !exists {
+		return database.GetPostByIDRow{}, sql.ErrNoRows
+	}
+	return postRow(post), nil
+}
+
+func (db *DB) GetPostByURL(ctx context.Context, url string) (database.GetPostByURLRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, post := range db.posts {
+		if post.Url == url {
+			return database.GetPostByURLRow(postRow(post)), nil
+		}
+	}
+	return database.GetPostByURLRow{}, sql.ErrNoRows
+}
+
+func (db *DB) GetRecentPostDates(ctx context.Context, arg database.GetRecentPostDatesParams) ([]sql.NullTime, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	dates := []sql.NullTime{}
+	for _, post := range db.posts {
+		if arg.FeedID.Valid && post.FeedID == arg.FeedID && post.PublishedAt.Valid {
+			dates = append(dates, post.PublishedAt)
+		}
+	}
+	slices.SortFunc(dates, func(a, b sql.NullTime) int {
+		return b.Time.Compare(a.Time)
+	})
+
+	var items []sql.NullTime
+	for _, date := range dates {
+		if len(items) >= int(arg.Limit) {
+			break
+		}
+		items = append(items, date)
+	}
+	return items, nil
+}
+
+// SearchPosts approximates the Postgres full text search: words and quoted
+// phrases match case-insensitively as substrings, without stemming.
+func (db *DB) SearchPosts(ctx context.Context, arg database.SearchPostsParams) ([]database.SearchPostsRow, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	groups := parseSearch(arg.Query)
+	matches := []database.SearchPostsRow{}
+	for _, post := range db.posts {
+		if !arg.AllFeeds && !db.isFollowing(arg.UserID, post.FeedID) {
+			continue
+		}
+		rank, matched := searchRank(post, groups)
+		if !matched {
+			continue
+		}
+
+		row := database.SearchPostsRow{
+			ID:          post.ID,
+			Title:       post.Title,
+			Url:         post.Url,
+			PublishedAt: post.PublishedAt,
+			Rank:        rank,
+			Headline:    searchHeadline(post, groups),
+		}
+		if post.FeedID.Valid {
+			feed, _ := db.feedByID(post.FeedID.UUID)
+			row.FeedName = feed.Name
+		}
+		matches = append(matches, row)
+	}
+
+	// rank DESC, published_at DESC NULLS LAST
+	slices.SortStableFunc(matches, func(a, b database.SearchPostsRow) int {
+		if c := cmp.Compare(b.Rank, a.Rank); c != 0 {
+			return c
+		}
+		return compareNullsFirst(b.PublishedAt, a.PublishedAt)
+	})
+
+	var items []database.SearchPostsRow
+	for _, row := range matches {
+		if len(items) >= int(arg.MaxResults) {
+			break
+		}
+		items = append(items, row)
+	}
+	return items, nil
+}
+
+// postRow returns the columns the post queries select, every one but the
+// search vector.
+func postRow(post database.Post) database.GetPostByIDRow {
+	return database.GetPostByIDRow{
+		ID:          post.ID,
+		CreatedAt:   post.CreatedAt,
+		UpdatedAt:   post.UpdatedAt,
+		Title:       post.Title,
+		Url:         post.Url,
+		Description: post.Description,
+		PublishedAt: post.PublishedAt,
+		FeedID:      post.FeedID,
+		Content:     post.Content,
+	}
+}
+
+func (db *DB) postByID(id uuid.UUID) (database.Post, bool) {
+	for _, post := range db.posts {
+		if post.ID == id {
+			return post, true
+		}
+	}
+	return database.Post{}, false
+}
+
+// searchTerm is a word or quoted phrase of a websearch_to_tsquery query.
+type searchTerm struct {
+	text    string
+	negated bool
+}
+
+// parseSearch splits a query into groups of terms that must all match, a
+// post matches when any group does. Groups are separated by "or".
+func parseSearch(query string) [][]searchTerm {
+	groups := [][]searchTerm{{}}
+	for query = strings.TrimSpace(query); query != ""; query = strings.TrimSpace(query) {
+		negated := false
+		if strings.HasPrefix(query, "-") {
+			negated, query = true, query[1:]
+		}
+
+		var text string
+		quoted := strings.HasPrefix(query, `"`)
+		if quoted {
+			end := strings.Index(query[1:], `"`)
+			if end < 0 {
+				end = len(query) - 1
+			}
+			text, query = query[1:end+1], query[min(end+2, len(query)):]
+		} else {
+			end := strings.IndexAny(query, " \t\n")
+			if end < 0 {
+				end = len(query)
+			}
+			text, query = query[:end], query[end:]
+		}
+
+		text = strings.ToLower(strings.TrimSpace(text))
+		switch {
+		case text == "":
+		case text == "or" && !quoted && !negated:
+			groups = append(groups, []searchTerm{})
+		default:
+			last := len(groups) - 1
+			groups[last] = append(groups[last], searchTerm{text: text, negated: negated})
+		}
+	}
+	return groups
+}
+
+// searchRank weighs matches in the title above the description, and the
+// description above the content, like the weights of search_vector.
+func searchRank(post database.Post, groups [][]searchTerm) (float32, bool) {
+	title := strings.ToLower(post.Title)
+	description := strings.ToLower(post.Description.String)
+	content := strings.ToLower(post.Content.String)
+
+	rank, matched := float32(0), false
+	for _, group := range groups {
+		groupMatched := len(group) > 0
+		groupRank := float32(0)
+		for _, term := range group {
+			count := strings.Count(title, term.text) + strings.Count(description, term.text) + strings.Count(content, term.text)
+			if (count > 0) == term.negated {
+				groupMatched = false
+				break
+			}
+			if !term.negated {
+				groupRank += 0.1 * (float32(strings.Count(title, term.text)) +
+					0.4*float32(strings.Count(description, term.text)) +
+					0.2*float32(strings.Count(content, term.text)))
+			}
+		}
+		if groupMatched {
+			matched = true
+			rank = max(rank, groupRank)
+		}
+	}
+	return rank, matched
+}
+
+// searchHeadline highlights the matched terms in the description, falling
+// back to the content and the title when it's empty, and keeps the first 35
+// words.
+func searchHeadline(post database.Post, groups [][]searchTerm) string {
+	text := post.Title
+	switch {
+	case post.Description.String != "":
+		text = post.Description.String
+	case post.Content.Valid:
+		text = post.Content.String
+	}
+
+	patterns := []string{}
+	for _, group := range groups {
+		for _, term := range group {
+			if !term.negated {
+				patterns = append(patterns, regexp.QuoteMeta(term.text))
+			}
+		}
+	}
+	if len(patterns) > 0 {
+		// Longest first, so a phrase wins over the words in it
+		slices.SortFunc(patterns, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+		highlight := regexp.MustCompile("(?i)" + strings.Join(patterns, "|"))
+		text = highlight.ReplaceAllString(text, "**$0**")
+	}
+
+	words := strings.Fields(text)
+	if len(words) > 35 {
+		words = words[:35]
+	}
+	return strings.Join(words, " ")
+}
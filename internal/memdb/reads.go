@@ -0,0 +1,114 @@
+package memdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/database"
+)
+
+func (db *DB) MarkFeedRead(ctx context.Context, arg database.MarkFeedReadParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, exists := db.userByID(arg.UserID); !exists {
+		return 0, foreignKeyViolation("post_reads_user_id_fkey")
+	}
+
+	rows := int64(0)
+	for _, post := range db.posts {
+		if arg.FeedID.Valid && post.FeedID == arg.FeedID && db.markRead(arg.UserID, post.ID, arg.ReadAt) {
+			rows++
+		}
+	}
+	return rows, nil
+}
+
+func (db *DB) MarkFeedUnread(ctx context.Context, arg database.MarkFeedUnreadParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	rows := int64(0)
+	for _, post := range db.posts {
+		if arg.FeedID.Valid && post.FeedID == arg.FeedID && db.markUnread(arg.UserID, post.ID) {
+			rows++
+		}
+	}
+	return rows, nil
+}
+
+func (db *DB) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, exists := db.userByID(arg.UserID); !exists {
+		return foreignKeyViolation("post_reads_user_id_fkey")
+	}
+	if _, exists := db.postByID(arg.PostID); !exists {
+		return foreignKeyViolation("post_reads_post_id_fkey")
+	}
+	db.markRead(arg.UserID, arg.PostID, arg.ReadAt)
+	return nil
+}
+
+func (db *DB) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.markUnread(arg.UserID, arg.PostID)
+	return nil
+}
+
+func (db *DB) MarkPostsReadBefore(ctx context.Context, arg database.MarkPostsReadBeforeParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	rows := int64(0)
+	for _, post := range db.posts {
+		if db.isFollowing(arg.UserID, post.FeedID) && postSortTime(post).Before(arg.Before) &&
+			db.markRead(arg.UserID, post.ID, arg.ReadAt) {
+			rows++
+		}
+	}
+	return rows, nil
+}
+
+func (db *DB) MarkPostsUnreadBefore(ctx context.Context, arg database.MarkPostsUnreadBeforeParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	rows := int64(0)
+	for _, post := range db.posts {
+		if postSortTime(post).Before(arg.Before) && db.markUnread(arg.UserID, post.ID) {
+			rows++
+		}
+	}
+	return rows, nil
+}
+
+// markRead inserts a read, doing nothing on conflict, and reports whether
+// it was inserted.
+func (db *DB) markRead(userID, postID uuid.UUID, readAt time.Time) bool {
+	key := readKey{UserID: userID, PostID: postID}
+	if _, exists := db.reads[key]; exists {
+		return false
+	}
+	db.reads[key] = readAt
+	return true
+}
+
+// markUnread deletes a read and reports whether there was one.
+func (db *DB) markUnread(userID, postID uuid.UUID) bool {
+	key := readKey{UserID: userID, PostID: postID}
+	if _, exists := db.reads[key]; !exists {
+		return false
+	}
+	delete(db.reads, key)
+	return true
+}
+
+func (db *DB) isRead(userID, postID uuid.UUID) bool {
+	_, exists := db.reads[readKey{UserID: userID, PostID: postID}]
+	return exists
+}
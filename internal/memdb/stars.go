@@ -0,0 +1,82 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/database"
+)
+
+// CreateStar snapshots the post, or updates the note of the existing star
+// of the same URL.
+func (db *DB) CreateStar(ctx context.Context, arg database.CreateStarParams) (database.Star, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	post, exists := db.postByID(arg.PostID)
+	if !exists {
+		return database.Star{}, sql.ErrNoRows
+	}
+	if _, exists := db.userByID(arg.UserID); !exists {
+		return database.Star{}, foreignKeyViolation("stars_user_id_fkey")
+	}
+
+	for i, star := range db.stars {
+		if star.UserID == arg.UserID && star.Url == post.Url {
+			db.stars[i].Note = arg.Note
+			db.stars[i].UpdatedAt = arg.UpdatedAt
+			return db.stars[i], nil
+		}
+	}
+
+	star := database.Star{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		UserID:      arg.UserID,
+		PostID:      uuid.NullUUID{UUID: post.ID, Valid: true},
+		Title:       post.Title,
+		Url:         post.Url,
+		Description: post.Description,
+		PublishedAt: post.PublishedAt,
+		Note:        arg.Note,
+		Content:     post.Content,
+	}
+	if post.FeedID.Valid {
+		feed, _ := db.feedByID(post.FeedID.UUID)
+		star.FeedName = feed.Name
+	}
+	db.stars = append(db.stars, star)
+	return star, nil
+}
+
+func (db *DB) DeleteStar(ctx context.Context, arg database.DeleteStarParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	before := len(db.stars)
+	db.stars = slices.DeleteFunc(db.stars, func(star database.Star) bool {
+		return star.UserID == arg.UserID && star.Url == arg.Url
+	})
+	return int64(before - len(db.stars)), nil
+}
+
+func (db *DB) GetStarsForUser(ctx context.Context, userID uuid.UUID) ([]database.Star, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	stars := []database.Star{}
+	for _, star := range db.stars {
+		if star.UserID == userID {
+			stars = append(stars, star)
+		}
+	}
+	slices.SortStableFunc(stars, func(a, b database.Star) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	var items []database.Star
+	return append(items, stars...), nil
+}
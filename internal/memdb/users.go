@@ -0,0 +1,71 @@
+package memdb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/database"
+)
+
+func (db *DB) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, user := range db.users {
+		if user.ID == arg.ID {
+			return database.User{}, uniqueViolation("users_pkey")
+		}
+		if user.Name == arg.Name {
+			return database.User{}, uniqueViolation("users_name_key")
+		}
+	}
+
+	user := database.User{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Name:      arg.Name,
+	}
+	db.users = append(db.users, user)
+	return user, nil
+}
+
+func (db *DB) GetUser(ctx context.Context, name string) (database.User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, user := range db.users {
+		if user.Name == name {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (db *DB) GetUserByID(ctx context.Context, id uuid.UUID) (string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	user, exists := db.userByID(id)
+	if !exists {
+		return "", sql.ErrNoRows
+	}
+	return user.Name, nil
+}
+
+func (db *DB) GetUsers(ctx context.Context) ([]database.User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return append([]database.User(nil), db.users...), nil
+}
+
+func (db *DB) userByID(id uuid.UUID) (database.User, bool) {
+	for _, user := range db.users {
+		if user.ID == id {
+			return user, true
+		}
+	}
+	return database.User{}, false
+}
@@ -10,14 +10,14 @@ import (
 )
 
 func MiddlewareLoggedIn(
-	handler func(s *config.State, cmd handling.Command, user database.User) error,
-) func(*config.State, handling.Command) error {
-	return func(s *config.State, cmd handling.Command) error {
-		user, err := s.Db.GetUser(context.Background(), s.State.CurrentUserName)
+	handler func(ctx context.Context, s *config.State, cmd handling.Command, user database.User) error,
+) func(context.Context, *config.State, handling.Command) error {
+	return func(ctx context.Context, s *config.State, cmd handling.Command) error {
+		user, err := s.Db.GetUser(ctx, s.State.CurrentUserName)
 		if err != nil {
 			return fmt.Errorf("Failed to retrieve user data from databse:\n%v\n", err)
 		}
 
-		return handler(s, cmd, user)
+		return handler(ctx, s, cmd, user)
 	}
 }
@@ -0,0 +1,276 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// versionTable is the table the goose CLI keeps its versions in, so
+// databases migrated with goose before are picked up where they were.
+const versionTable = "goose_db_version"
+
+// Load parses the numbered .sql files in dir of fsys, sorted by version.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	names, err := fs.Glob(fsys, path.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []Migration{}
+	seen := map[int64]string{}
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read migration %v:\n%v\n", name, err)
+		}
+		migration, err := parse(path.Base(name), string(data))
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[migration.Version]; ok {
+			return nil, fmt.Errorf("Migrations %v and %v share version %v", other, migration.Name, migration.Version)
+		}
+		seen[migration.Version] = migration.Name
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// parse splits a migration file on its goose annotations.
+func parse(name, body string) (Migration, error) {
+	prefix, _, found := strings.Cut(name, "_")
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if !found || err != nil || version < 1 {
+		return Migration{}, fmt.Errorf("Migration %v doesnt start with a version number", name)
+	}
+
+	migration := Migration{Version: version, Name: name}
+	var up, down strings.Builder
+	var section *strings.Builder
+	for _, line := range strings.SplitAfter(body, "\n") {
+		annotation, isAnnotation := strings.CutPrefix(strings.TrimSpace(line), "-- +goose ")
+		if !isAnnotation {
+			if section != nil {
+				section.WriteString(line)
+			}
+			continue
+		}
+
+		switch strings.TrimSpace(annotation) {
+		case "Up":
+			section = &up
+		case "Down":
+			section = &down
+		case "NO TRANSACTION":
+			migration.NoTransaction = true
+		case "StatementBegin", "StatementEnd":
+			// Sections run as a single batch, there is nothing to split
+		default:
+			return Migration{}, fmt.Errorf("Unknown annotation '%v' in migration %v", annotation, name)
+		}
+	}
+
+	migration.Up = strings.TrimSpace(up.String())
+	migration.Down = strings.TrimSpace(down.String())
+	if migration.Up == "" {
+		return Migration{}, fmt.Errorf("Migration %v has no '-- +goose Up' section", name)
+	}
+	return migration, nil
+}
+
+// Version returns the newest applied version, 0 for an empty database.
+func Version(ctx context.Context, db *sql.DB, dialect Dialect) (int64, error) {
+	applied, err := appliedVersions(ctx, db, dialect)
+	if err != nil {
+		return 0, err
+	}
+
+	version := int64(0)
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+// Statuses reports which migrations have been applied.
+func Statuses(ctx context.Context, db *sql.DB, schema Schema) ([]Status, error) {
+	applied, err := appliedVersions(ctx, db, schema.Dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	for _, migration := range schema.Migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that haven't been applied yet.
+func Pending(ctx context.Context, db *sql.DB, schema Schema) ([]Migration, error) {
+	statuses, err := Statuses(ctx, db, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []Migration{}
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies the pending migrations in order and returns them.
+func Up(ctx context.Context, db *sql.DB, schema Schema) ([]Migration, error) {
+	err := ensureVersionTable(ctx, db, schema.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := Pending(ctx, db, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, migration := range pending {
+		err = apply(ctx, db, migration, migration.Up, schema.Dialect.insertVersion)
+		if err != nil {
+			return pending[:i], err
+		}
+	}
+	return pending, nil
+}
+
+// Down rolls back the newest applied migration, nil when there is none.
+func Down(ctx context.Context, db *sql.DB, schema Schema) (*Migration, error) {
+	version, err := Version(ctx, db, schema.Dialect)
+	if err != nil || version == 0 {
+		return nil, err
+	}
+
+	for _, migration := range schema.Migrations {
+		if migration.Version != version {
+			continue
+		}
+		err = apply(ctx, db, migration, migration.Down, schema.Dialect.deleteVersion)
+		if err != nil {
+			return nil, err
+		}
+		return &migration, nil
+	}
+	return nil, fmt.Errorf("Database is at version %v, which has no migration file", version)
+}
+
+// apply runs one direction of a migration and records it, in a single
+// transaction unless the migration opted out.
+func apply(ctx context.Context, db *sql.DB, migration Migration, statements, record string) error {
+	if migration.NoTransaction {
+		err := exec(ctx, db, statements)
+		if err == nil {
+			_, err = db.ExecContext(ctx, record, migration.Version)
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to run migration %v:\n%v\n", migration.Name, err)
+		}
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = exec(ctx, tx, statements)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, record, migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to run migration %v:\n%v\n", migration.Name, err)
+	}
+	return tx.Commit()
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func exec(ctx context.Context, db execer, statements string) error {
+	if statements == "" {
+		return nil
+	}
+	// Without arguments the whole section is sent as one multi-statement query
+	_, err := db.ExecContext(ctx, statements)
+	return err
+}
+
+func ensureVersionTable(ctx context.Context, db *sql.DB, dialect Dialect) error {
+	_, err := db.ExecContext(ctx, dialect.createVersionTable)
+	if err != nil {
+		return fmt.Errorf("Failed to create %v:\n%v\n", versionTable, err)
+	}
+	return nil
+}
+
+// appliedVersions replays the version table. Old goose versions recorded a
+// rollback as a row with is_applied false, newer ones delete the row.
+func appliedVersions(ctx context.Context, db *sql.DB, dialect Dialect) (map[int64]time.Time, error) {
+	applied := map[int64]time.Time{}
+
+	exists, err := versionTableExists(ctx, db, dialect)
+	if err != nil || !exists {
+		return applied, err
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT version_id, is_applied, tstamp FROM goose_db_version ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %v:\n%v\n", versionTable, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var tstamp sql.NullTime
+		err = rows.Scan(&version, &isApplied, &tstamp)
+		if err != nil {
+			return nil, err
+		}
+		// goose records version 0 when it creates the table
+		if version == 0 {
+			continue
+		}
+		if isApplied {
+			applied[version] = tstamp.Time
+		} else {
+			delete(applied, version)
+		}
+	}
+	return applied, rows.Err()
+}
+
+func versionTableExists(ctx context.Context, db *sql.DB, dialect Dialect) (bool, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx, dialect.versionTableExists).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("Failed to look for %v:\n%v\n", versionTable, err)
+	}
+	return exists, nil
+}
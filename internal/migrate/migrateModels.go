@@ -0,0 +1,62 @@
+package migrate
+
+import "time"
+
+// Migration is one numbered file of the schema, in goose format.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// NoTransaction is set by "-- +goose NO TRANSACTION", for statements
+	// such as CREATE INDEX CONCURRENTLY
+	NoTransaction bool
+}
+
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Schema is the migrations of one database backend.
+type Schema struct {
+	Dialect    Dialect
+	Migrations []Migration
+}
+
+// Dialect holds the SQL for the version table, which differs between
+// databases. The tables match the ones goose creates for each of them.
+type Dialect struct {
+	Name               string
+	createVersionTable string
+	versionTableExists string
+	insertVersion      string
+	deleteVersion      string
+}
+
+var Postgres = Dialect{
+	Name: "postgres",
+	createVersionTable: `CREATE TABLE IF NOT EXISTS goose_db_version (
+    id SERIAL PRIMARY KEY,
+    version_id BIGINT NOT NULL,
+    is_applied BOOLEAN NOT NULL,
+    tstamp TIMESTAMP DEFAULT now()
+)`,
+	versionTableExists: `SELECT to_regclass('goose_db_version') IS NOT NULL`,
+	insertVersion:      `INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, TRUE)`,
+	deleteVersion:      `DELETE FROM goose_db_version WHERE version_id = $1`,
+}
+
+var SQLite = Dialect{
+	Name: "sqlite",
+	createVersionTable: `CREATE TABLE IF NOT EXISTS goose_db_version (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version_id INTEGER NOT NULL,
+    is_applied INTEGER NOT NULL,
+    tstamp TIMESTAMP DEFAULT (datetime('now'))
+)`,
+	versionTableExists: `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'goose_db_version')`,
+	insertVersion:      `INSERT INTO goose_db_version (version_id, is_applied) VALUES (?, TRUE)`,
+	deleteVersion:      `DELETE FROM goose_db_version WHERE version_id = ?`,
+}
@@ -0,0 +1,124 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Parse reads an OPML document and flattens its outlines into subscriptions.
+func Parse(r io.Reader) ([]Subscription, error) {
+	var doc OPML
+	err := xml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal opml:\n%v\n", err)
+	}
+
+	subs := []Subscription{}
+	collect(doc.Body, nil, &subs)
+	return subs, nil
+}
+
+func collect(outlines []Outline, folders []string, subs *[]Subscription) {
+	for _, outline := range outlines {
+		name := outline.Title
+		if name == "" {
+			name = outline.Text
+		}
+
+		// Outlines with a feed URL are subscriptions, anything else is a folder
+		if outline.XMLURL != "" {
+			*subs = append(*subs, Subscription{
+				Name:     name,
+				URL:      outline.XMLURL,
+				Category: strings.Join(folders, "/"),
+			})
+			continue
+		}
+
+		// A folder without a name adds nothing to the path
+		if name == "" {
+			collect(outline.Outlines, folders, subs)
+			continue
+		}
+		collect(outline.Outlines, append(folders[:len(folders):len(folders)], name), subs)
+	}
+}
+
+// Write renders subscriptions as an OPML 2.0 document. A category is
+// written as nested folder outlines, one per part of its path, the way
+// Parse reads them.
+func Write(w io.Writer, owner string, subs []Subscription) error {
+	doc := OPML{
+		Version: "2.0",
+		Head: Head{
+			Title:       "gator subscriptions",
+			DateCreated: time.Now().Format(time.RFC1123Z),
+			OwnerName:   owner,
+		},
+	}
+
+	for _, sub := range subs {
+		outline := Outline{
+			Text:   sub.Name,
+			Title:  sub.Name,
+			Type:   "rss",
+			XMLURL: sub.URL,
+		}
+		var folders []string
+		if sub.Category != "" {
+			folders = strings.Split(sub.Category, "/")
+		}
+		insert(&doc.Body, folders, outline)
+	}
+	sortFolders(doc.Body)
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("Failed to marshal opml:\n%v\n", err)
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// insert adds outline inside the folders, creating the ones missing.
+func insert(outlines *[]Outline, folders []string, outline Outline) {
+	if len(folders) == 0 {
+		*outlines = append(*outlines, outline)
+		return
+	}
+
+	for i := range *outlines {
+		folder := &(*outlines)[i]
+		if folder.XMLURL == "" && folder.Text == folders[0] {
+			insert(&folder.Outlines, folders[1:], outline)
+			return
+		}
+	}
+	*outlines = append(*outlines, Outline{Text: folders[0], Title: folders[0]})
+	insert(&(*outlines)[len(*outlines)-1].Outlines, folders[1:], outline)
+}
+
+// sortFolders puts the feeds of each level first, in the order given, and
+// then its folders by name.
+func sortFolders(outlines []Outline) {
+	sort.SliceStable(outlines, func(i, j int) bool {
+		a, b := outlines[i], outlines[j]
+		if (a.XMLURL == "") != (b.XMLURL == "") {
+			return a.XMLURL != ""
+		}
+		return a.XMLURL == "" && a.Text < b.Text
+	})
+	for _, outline := range outlines {
+		sortFolders(outline.Outlines)
+	}
+}
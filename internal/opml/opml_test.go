@@ -0,0 +1,67 @@
+package opml
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseNestedFolders(t *testing.T) {
+	subs, err := Parse(strings.NewReader(`<?xml version="1.0"?>
+<opml version="2.0"><head><title>Feeds</title></head><body>
+  <outline text="Top" xmlUrl="https://top.example/rss"/>
+  <outline text="Tech">
+    <outline text="Go">
+      <outline text="Go Blog" xmlUrl="https://go.dev/blog/feed.atom"/>
+    </outline>
+    <outline title="Rust" text="rust">
+      <outline text="This Week in Rust" xmlUrl="https://this-week-in-rust.org/rss.xml"/>
+    </outline>
+    <outline text="">
+      <outline text="Unnamed" xmlUrl="https://unnamed.example/rss"/>
+    </outline>
+  </outline>
+</body></opml>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Subscription{
+		{"Top", "https://top.example/rss", ""},
+		{"Go Blog", "https://go.dev/blog/feed.atom", "Tech/Go"},
+		{"This Week in Rust", "https://this-week-in-rust.org/rss.xml", "Tech/Rust"},
+		{"Unnamed", "https://unnamed.example/rss", "Tech"},
+	}
+	if fmt.Sprint(subs) != fmt.Sprint(want) {
+		t.Errorf("Parse = %v, want %v", subs, want)
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	subs := []Subscription{
+		{"Top", "https://top.example/rss", ""},
+		{"Go Blog", "https://go.dev/blog/feed.atom", "Tech/Go"},
+		{"Lobsters", "https://lobste.rs/rss", "Tech"},
+		{"Go Weekly", "https://golangweekly.com/rss", "Tech/Go"},
+	}
+
+	var buf bytes.Buffer
+	err := Write(&buf, "alice", subs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), `"Tech/Go"`) {
+		t.Errorf("Write kept Tech/Go as a single folder:\n%v", buf.String())
+	}
+
+	parsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each level lists its feeds before its folders
+	want := []Subscription{subs[0], subs[2], subs[1], subs[3]}
+	if fmt.Sprint(parsed) != fmt.Sprint(want) {
+		t.Errorf("Parse(Write) = %v, want %v", parsed, want)
+	}
+}
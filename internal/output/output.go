@@ -0,0 +1,137 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const rule = "════════════════════════════════════════════════════════════"
+
+// ParseFormat validates the value of --output.
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(value)); format {
+	case Text, Table, JSON, NDJSON, CSV:
+		return format, nil
+	}
+	return "", fmt.Errorf("Unknown output format '%s'. Expected text, table, json, ndjson or csv", value)
+}
+
+// Machine reports whether format is meant for scripts rather than people.
+func (f Format) Machine() bool {
+	return f == JSON || f == NDJSON || f == CSV
+}
+
+// Render writes records to w in format.
+func Render[T Record](w io.Writer, format Format, records []T) error {
+	switch format {
+	case JSON:
+		// An empty list is [] rather than null
+		if records == nil {
+			records = []T{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case NDJSON:
+		enc := json.NewEncoder(w)
+		for _, record := range records {
+			err := enc.Encode(record)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case CSV:
+		return writeCSV(w, records)
+	case Table:
+		return writeTable(w, records)
+	default:
+		return writeText(w, records)
+	}
+}
+
+// RenderOne writes a single record, as an object rather than a list in json.
+func RenderOne[T Record](w io.Writer, format Format, record T) error {
+	if format == JSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(record)
+	}
+	return Render(w, format, []T{record})
+}
+
+func writeCSV[T Record](w io.Writer, records []T) error {
+	var zero T
+	cw := csv.NewWriter(w)
+	err := cw.Write(zero.Columns())
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		err = cw.Write(record.Values())
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeTable[T Record](w io.Writer, records []T) error {
+	var zero T
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := zero.Columns()
+	for i := range header {
+		header[i] = strings.ToUpper(header[i])
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, record := range records {
+		values := record.Values()
+		for i := range values {
+			// Tabs and newlines would break the columns
+			values[i] = strings.Join(strings.Fields(values[i]), " ")
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeText[T Record](w io.Writer, records []T) error {
+	var zero T
+	if _, ok := any(zero).(Texter); !ok {
+		return writeTable(w, records)
+	}
+
+	framed := false
+	if f, ok := any(zero).(Framed); ok {
+		framed = f.Framed()
+	}
+	for _, record := range records {
+		if framed {
+			fmt.Fprintln(w, rule)
+		}
+		fmt.Fprint(w, any(record).(Texter).Text())
+	}
+	if framed && len(records) > 0 {
+		fmt.Fprintln(w, rule)
+	}
+	return nil
+}
+
+// String and Set let a Format be used as a flag.Value.
+func (f *Format) String() string {
+	return string(*f)
+}
+
+func (f *Format) Set(value string) error {
+	format, err := ParseFormat(value)
+	if err != nil {
+		return err
+	}
+	*f = format
+	return nil
+}
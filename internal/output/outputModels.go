@@ -0,0 +1,29 @@
+package output
+
+type Format string
+
+const (
+	Text   Format = "text"
+	Table  Format = "table"
+	JSON   Format = "json"
+	NDJSON Format = "ndjson"
+	CSV    Format = "csv"
+)
+
+// Record is a single row of command output. Columns and Values are used by
+// the table and csv formats, json and ndjson marshal the record itself.
+type Record interface {
+	Columns() []string
+	Values() []string
+}
+
+// Texter is implemented by records with their own human readable layout.
+// Records without one are printed as a table in the text format.
+type Texter interface {
+	Text() string
+}
+
+// Framed records are printed between rule lines in the text format.
+type Framed interface {
+	Framed() bool
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+type testRecord struct {
+	Name string  `json:"name"`
+	Note *string `json:"note,omitempty"`
+	Tags string  `json:"tags"`
+}
+
+func (r testRecord) Columns() []string { return []string{"name", "note", "tags"} }
+
+func (r testRecord) Values() []string {
+	note := ""
+	if r.Note != nil {
+		note = *r.Note
+	}
+	return []string{r.Name, note, r.Tags}
+}
+
+func (r testRecord) Framed() bool { return true }
+
+func (r testRecord) Text() string {
+	if r.Note == nil {
+		return fmt.Sprintf("%v [%v]\n", r.Name, r.Tags)
+	}
+	return fmt.Sprintf("%v [%v]\n%v\n", r.Name, r.Tags, *r.Note)
+}
+
+func TestRenderGolden(t *testing.T) {
+	note := func(s string) *string { return &s }
+	records := []testRecord{
+		{Name: "plain", Note: note("simple"), Tags: "go"},
+		{Name: "Comma, Inc.", Note: note("line one\nline two"), Tags: "a,b"},
+		{Name: `say "hi"`, Note: nil, Tags: ""},
+		{Name: "tab\there", Note: note(""), Tags: "\ttrimmed "},
+	}
+
+	for _, format := range []Format{Text, Table, JSON, NDJSON, CSV} {
+		t.Run(string(format), func(t *testing.T) {
+			var got bytes.Buffer
+			err := Render(&got, format, records)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "records."+string(format))
+			if *update {
+				err = os.WriteFile(golden, got.Bytes(), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("%v output differs from %v:\n%s\nwant:\n%s", format, golden, got.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestRenderEmpty(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{JSON, "[]\n"},
+		{NDJSON, ""},
+		{CSV, "name,note,tags\n"},
+		{Text, ""},
+	}
+	for _, test := range tests {
+		var got bytes.Buffer
+		err := Render[testRecord](&got, test.format, nil)
+		if err != nil || got.String() != test.want {
+			t.Errorf("Render(%v, nil) = %q, %v, want %q", test.format, got.String(), err, test.want)
+		}
+	}
+}
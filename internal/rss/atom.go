@@ -0,0 +1,90 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+func parseAtom(body []byte) (*RSSFeed, error) {
+	var atom AtomFeed
+	err := xml.Unmarshal(body, &atom)
+	if err != nil {
+		return &RSSFeed{}, fmt.Errorf("Failed to unmarshal atom feed:\n%v\n", err)
+	}
+
+	feed := &RSSFeed{}
+	feed.Channel.Title = atom.Title
+	feed.Channel.Link = alternateLink(atom.Links)
+	feed.Channel.Description = atom.Subtitle
+	feed.Channel.Image.URL = atom.Icon
+	if feed.Channel.Image.URL == "" {
+		feed.Channel.Image.URL = atom.Logo
+	}
+
+	for _, entry := range atom.Entries {
+		item := RSSItem{
+			Title:       entry.Title,
+			Link:        alternateLink(entry.Links),
+			Description: entry.Summary.String(),
+			PubDate:     entry.Published,
+			GUID:        entry.ID,
+			Content:     entry.Content.String(),
+		}
+		if item.Description == "" {
+			item.Description = item.Content
+		}
+		// Atom only requires <updated>, <published> is optional
+		if item.PubDate == "" {
+			item.PubDate = entry.Updated
+		}
+		if item.Link == "" {
+			item.Link = entry.ID
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+
+	return feed, nil
+}
+
+// alternateLink picks the link pointing at the html version of the entry.
+// A link without a rel attribute counts as "alternate" per RFC 4287. Other
+// links, such as self or enclosure, point at something else, so without an
+// alternate there is no link.
+func alternateLink(links []AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// String returns the text, or for xhtml the markup inside the wrapping div,
+// which the character data alone would have stripped of its tags.
+func (t AtomText) String() string {
+	if t.Type != "xhtml" {
+		return t.Text
+	}
+
+	inner := strings.TrimSpace(t.Inner)
+	end := strings.Index(inner, ">")
+	if !strings.HasPrefix(inner, "<") || end < 0 {
+		return inner
+	}
+	tag := strings.Fields(inner[1:end])
+	if len(tag) == 0 {
+		return inner
+	}
+	// The div may carry a namespace prefix, the closing tag repeats it
+	name := tag[0]
+	_, local, found := strings.Cut(name, ":")
+	if !found {
+		local = name
+	}
+	closing := "</" + name + ">"
+	if local != "div" || !strings.HasSuffix(inner, closing) {
+		return inner
+	}
+	return strings.TrimSpace(inner[end+1 : len(inner)-len(closing)])
+}
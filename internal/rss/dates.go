@@ -0,0 +1,143 @@
+package rss
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// dateLayouts are tried in order once the day name and named time zone have
+// been normalised away. Go's "2" accepts both one and two digit days.
+var dateLayouts = []string{
+	// RFC 822 / RFC 1123 and the usual deviations from it
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04 -07:00",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 January 2006 15:04:05 -0700",
+	"2 January 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Jan 2, 2006 15:04:05 -0700",
+	"January 2, 2006 15:04:05 -0700",
+	"Jan 2, 2006",
+	"January 2, 2006",
+
+	// ISO 8601 / RFC 3339, as used by Atom, JSON Feed and dc:date
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// zoneOffsets maps the named zones that show up in feeds to their offsets.
+// time.Parse only understands abbreviations of the local zone and silently
+// treats every other one as UTC.
+var zoneOffsets = map[string]string{
+	"GMT":  "+0000",
+	"UT":   "+0000",
+	"UTC":  "+0000",
+	"Z":    "+0000",
+	"EST":  "-0500",
+	"EDT":  "-0400",
+	"CST":  "-0600",
+	"CDT":  "-0500",
+	"MST":  "-0700",
+	"MDT":  "-0600",
+	"PST":  "-0800",
+	"PDT":  "-0700",
+	"AKST": "-0900",
+	"AKDT": "-0800",
+	"HST":  "-1000",
+	"WET":  "+0000",
+	"WEST": "+0100",
+	"BST":  "+0100",
+	"CET":  "+0100",
+	"CEST": "+0200",
+	"MET":  "+0100",
+	"MEST": "+0200",
+	"EET":  "+0200",
+	"EEST": "+0300",
+	"MSK":  "+0300",
+	"IST":  "+0530",
+	"SGT":  "+0800",
+	"HKT":  "+0800",
+	"JST":  "+0900",
+	"KST":  "+0900",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+	"NZST": "+1200",
+	"NZDT": "+1300",
+}
+
+// ParseDate parses the publication dates found in RSS, RDF, Atom and JSON
+// feeds. Dates without a zone are assumed to be UTC. An error is returned
+// when the value matches none of the known formats so the caller can store
+// the date as unknown instead of guessing.
+func ParseDate(value string) (time.Time, error) {
+	normalised := normaliseDate(value)
+	if normalised == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+
+	for _, layout := range dateLayouts {
+		parsed, err := time.Parse(layout, normalised)
+		if err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognised date format '%s'", value)
+}
+
+// normaliseDate strips the leading day name, which is often localised or
+// misspelled and carries no information, and swaps a trailing named zone
+// for its numeric offset.
+func normaliseDate(value string) string {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	if len(fields) >= 3 && isWord(fields[0]) && startsWithDigit(fields[1]) && isWord(fields[2]) {
+		fields = fields[1:]
+	} else if strings.HasSuffix(fields[0], ",") && isWord(fields[0]) && len(fields) > 1 && startsWithDigit(fields[1]) {
+		fields = fields[1:]
+	}
+
+	last := len(fields) - 1
+	if offset, ok := zoneOffsets[strings.ToUpper(fields[last])]; ok {
+		fields[last] = offset
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// isWord reports whether s is made of letters only, ignoring the trailing
+// punctuation feeds put after day names.
+func isWord(s string) bool {
+	s = strings.TrimRight(s, ",.")
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func startsWithDigit(s string) bool {
+	return s != "" && unicode.IsDigit(rune(s[0]))
+}
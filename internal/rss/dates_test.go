@@ -0,0 +1,62 @@
+package rss
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	pdt := time.FixedZone("", -7*60*60)
+	cest := time.FixedZone("", 2*60*60)
+
+	cases := []struct {
+		name  string
+		input string
+		want  time.Time
+	}{
+		{"RFC1123Z", "Mon, 02 Jan 2006 15:04:05 -0700", time.Date(2006, 1, 2, 22, 4, 5, 0, time.UTC)},
+		{"RFC1123 GMT", "Mon, 02 Jan 2006 15:04:05 GMT", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"RFC822 UT", "02 Jan 06 15:04 UT", time.Date(2006, 1, 2, 15, 4, 0, 0, time.UTC)},
+		{"two digit year", "Tue, 10 Jun 03 09:41:01 +0000", time.Date(2003, 6, 10, 9, 41, 1, 0, time.UTC)},
+		{"single digit day", "Sat, 7 Sep 2024 08:00:00 +0000", time.Date(2024, 9, 7, 8, 0, 0, 0, time.UTC)},
+		{"missing seconds", "Wed, 15 May 2024 10:30 +0200", time.Date(2024, 5, 15, 10, 30, 0, 0, cest)},
+		{"named zone PDT", "Fri, 12 Jul 2024 09:00:00 PDT", time.Date(2024, 7, 12, 9, 0, 0, 0, pdt)},
+		{"named zone lowercase", "Fri, 12 Jul 2024 09:00:00 pdt", time.Date(2024, 7, 12, 9, 0, 0, 0, pdt)},
+		{"colon offset", "Fri, 12 Jul 2024 09:00:00 -07:00", time.Date(2024, 7, 12, 9, 0, 0, 0, pdt)},
+		{"no zone", "Fri, 12 Jul 2024 09:00:00", time.Date(2024, 7, 12, 9, 0, 0, 0, time.UTC)},
+		{"french day name", "mer., 15 May 2024 10:30:00 +0200", time.Date(2024, 5, 15, 10, 30, 0, 0, cest)},
+		{"day name without comma", "Wed 15 May 2024 10:30:00 +0200", time.Date(2024, 5, 15, 10, 30, 0, 0, cest)},
+		{"wrong day name", "Sun, 15 May 2024 10:30:00 +0200", time.Date(2024, 5, 15, 10, 30, 0, 0, cest)},
+		{"full month name", "15 May 2024", time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)},
+		{"US style", "July 12, 2024", time.Date(2024, 7, 12, 0, 0, 0, 0, time.UTC)},
+		{"extra whitespace", "  Mon,  02 Jan 2006   15:04:05 +0000 ", time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"RFC3339", "2024-03-01T12:00:00Z", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"RFC3339 offset", "2024-03-01T12:00:00-07:00", time.Date(2024, 3, 1, 12, 0, 0, 0, pdt)},
+		{"RFC3339 fractional", "2024-03-01T12:00:00.123Z", time.Date(2024, 3, 1, 12, 0, 0, 123000000, time.UTC)},
+		{"ISO8601 compact offset", "2024-03-01T12:00:00+0200", time.Date(2024, 3, 1, 12, 0, 0, 0, cest)},
+		{"ISO8601 no seconds", "2024-03-01T12:00+02:00", time.Date(2024, 3, 1, 12, 0, 0, 0, cest)},
+		{"ISO8601 local", "2024-03-01T12:00:00", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"ISO8601 space", "2024-03-01 12:00:00", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"dc:date day only", "2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"empty", "", time.Time{}},
+		{"garbage", "yesterday", time.Time{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParseDate(c.input)
+			if c.want.IsZero() {
+				if err == nil {
+					t.Fatalf("ParseDate(%q) = %v, want error", c.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDate(%q) returned error: %v", c.input, err)
+			}
+			if !got.Equal(c.want) {
+				t.Errorf("ParseDate(%q) = %v, want %v", c.input, got, c.want)
+			}
+		})
+	}
+}
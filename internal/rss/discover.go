@@ -0,0 +1,155 @@
+package rss
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// commonFeedPaths are probed when a page doesn't advertise its feeds.
+var commonFeedPaths = []string{
+	"/feed",
+	"/feed/",
+	"/rss",
+	"/rss.xml",
+	"/feed.xml",
+	"/atom.xml",
+	"/index.xml",
+	"/feed.json",
+}
+
+var feedTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/rdf+xml":   true,
+	"application/feed+json": true,
+	"application/json":      true,
+}
+
+var (
+	linkTagPattern   = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	attributePattern = regexp.MustCompile(`(?is)([a-z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// Discover resolves pageURL to the feeds it offers. A feed URL resolves to
+// itself. For an HTML page the <link rel="alternate"> feeds are used, falling
+// back to probing commonFeedPaths. Only URLs that parse as a feed are returned.
+func Discover(ctx context.Context, pageURL string) ([]FeedLink, error) {
+	body, header, finalURL, err := fetchDocument(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	feed, err := parseFeed(body, header.Get("Content-Type"))
+	if err == nil {
+		return []FeedLink{newFeedLink(FeedLink{URL: pageURL}, feed, header)}, nil
+	}
+
+	candidates := alternateFeedLinks(body, finalURL)
+	if len(candidates) == 0 {
+		for _, path := range commonFeedPaths {
+			candidates = append(candidates, FeedLink{URL: finalURL.ResolveReference(&url.URL{Path: path}).String()})
+		}
+	}
+
+	links := []FeedLink{}
+	seen := map[string]bool{}
+	for _, candidate := range candidates {
+		if seen[candidate.URL] {
+			continue
+		}
+		seen[candidate.URL] = true
+
+		body, header, _, err := fetchDocument(ctx, candidate.URL)
+		if err != nil {
+			continue
+		}
+		feed, err := parseFeed(body, header.Get("Content-Type"))
+		if err != nil {
+			continue
+		}
+		links = append(links, newFeedLink(candidate, feed, header))
+	}
+
+	if len(links) == 0 {
+		return nil, fmt.Errorf("No feeds found at '%s'", pageURL)
+	}
+	return links, nil
+}
+
+// newFeedLink fills in link with the feed it was found to point at, as
+// FetchFeed would have returned it.
+func newFeedLink(link FeedLink, feed *RSSFeed, header http.Header) FeedLink {
+	link.Feed = feed
+	link.Cache = cacheHeaders(header)
+	if link.Title == "" {
+		link.Title = link.Feed.Channel.Title
+	}
+	return link
+}
+
+func fetchDocument(ctx context.Context, pageURL string) ([]byte, http.Header, *url.URL, error) {
+	ctx, cancel := withFetchTimeout(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Failed to create client:\n%v\n", err)
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Request failed:\n%v\n", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, nil, nil, &HTTPError{StatusCode: res.StatusCode, Status: res.Status}
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Failed to read response body:\n%v\n", err)
+	}
+
+	// Relative links resolve against the page we ended up on after redirects
+	return body, res.Header, res.Request.URL, nil
+}
+
+// alternateFeedLinks scans an HTML page for <link rel="alternate"> tags
+// pointing at a feed.
+func alternateFeedLinks(body []byte, base *url.URL) []FeedLink {
+	links := []FeedLink{}
+	for _, tag := range linkTagPattern.FindAll(body, -1) {
+		attrs := map[string]string{}
+		for _, match := range attributePattern.FindAllSubmatch(tag, -1) {
+			value := string(match[2]) + string(match[3]) + string(match[4])
+			attrs[strings.ToLower(string(match[1]))] = html.UnescapeString(value)
+		}
+
+		if !hasToken(attrs["rel"], "alternate") || !feedTypes[strings.ToLower(attrs["type"])] {
+			continue
+		}
+		href, err := base.Parse(strings.TrimSpace(attrs["href"]))
+		if err != nil || attrs["href"] == "" {
+			continue
+		}
+		links = append(links, FeedLink{URL: href.String(), Title: attrs["title"]})
+	}
+	return links
+}
+
+func hasToken(list, token string) bool {
+	for _, field := range strings.Fields(list) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
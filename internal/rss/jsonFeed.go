@@ -0,0 +1,87 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+func parseJSONFeed(body []byte) (*RSSFeed, error) {
+	var jsonFeed JSONFeed
+	err := json.Unmarshal(body, &jsonFeed)
+	if err != nil {
+		return &RSSFeed{}, fmt.Errorf("Failed to unmarshal json feed:\n%v\n", err)
+	}
+	if !strings.HasPrefix(jsonFeed.Version, "https://jsonfeed.org/version/") {
+		return &RSSFeed{}, fmt.Errorf("Not a feed, json document has no JSON Feed version")
+	}
+
+	feed := &RSSFeed{}
+	feed.Channel.Title = jsonFeed.Title
+	feed.Channel.Link = jsonFeed.HomePageURL
+	feed.Channel.Description = jsonFeed.Description
+	feed.Channel.Image.URL = jsonFeed.Favicon
+	if feed.Channel.Image.URL == "" {
+		feed.Channel.Image.URL = jsonFeed.Icon
+	}
+
+	for _, entry := range jsonFeed.Items {
+		item := RSSItem{
+			Title:       entry.Title,
+			Link:        entry.URL,
+			Description: entry.Summary,
+			PubDate:     entry.DatePublished,
+			GUID:        entry.ID,
+			Author:      authorNames(entry.Authors, entry.Author),
+			Content:     entry.ContentHTML,
+		}
+		if item.Content == "" {
+			item.Content = entry.ContentText
+		}
+		if item.Link == "" {
+			item.Link = entry.ExternalURL
+		}
+		if item.Description == "" {
+			item.Description = entry.ContentHTML
+		}
+		if item.Description == "" {
+			item.Description = entry.ContentText
+		}
+		if item.PubDate == "" {
+			item.PubDate = entry.DateModified
+		}
+		// Fall back to the feed level authors when the item has none
+		if item.Author == "" {
+			item.Author = authorNames(jsonFeed.Authors, jsonFeed.Author)
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+
+	return feed, nil
+}
+
+// authorNames joins the names of authors. The 1.0 author is only used
+// without 1.1 authors, feeds serving both repeat the same person in each.
+func authorNames(authors []JSONFeedUser, legacy *JSONFeedUser) string {
+	if len(authors) == 0 && legacy != nil {
+		authors = []JSONFeedUser{*legacy}
+	}
+
+	names := []string{}
+	for _, author := range authors {
+		if author.Name != "" {
+			names = append(names, author.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// isJSONFeed reports whether the response should be decoded as a JSON Feed,
+// either because the server said so or because the body looks like json.
+func isJSONFeed(contentType string, body []byte) bool {
+	if strings.Contains(contentType, "json") {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+}
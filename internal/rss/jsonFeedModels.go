@@ -0,0 +1,35 @@
+package rss
+
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Icon        string         `json:"icon"`
+	Favicon     string         `json:"favicon"`
+	Items       []JSONFeedItem `json:"items"`
+	Authors     []JSONFeedUser `json:"authors"`
+	// Author is the JSON Feed 1.0 field, deprecated in 1.1 in favour of Authors
+	Author *JSONFeedUser `json:"author"`
+}
+
+type JSONFeedItem struct {
+	ID            string         `json:"id"`
+	URL           string         `json:"url"`
+	ExternalURL   string         `json:"external_url"`
+	Title         string         `json:"title"`
+	ContentHTML   string         `json:"content_html"`
+	ContentText   string         `json:"content_text"`
+	Summary       string         `json:"summary"`
+	DatePublished string         `json:"date_published"`
+	DateModified  string         `json:"date_modified"`
+	Authors       []JSONFeedUser `json:"authors"`
+	// Author is the JSON Feed 1.0 field, deprecated in 1.1 in favour of Authors
+	Author *JSONFeedUser `json:"author"`
+}
+
+type JSONFeedUser struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
@@ -0,0 +1,39 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+func parseRDF(body []byte) (*RSSFeed, error) {
+	var rdf RDFFeed
+	err := xml.Unmarshal(body, &rdf)
+	if err != nil {
+		return &RSSFeed{}, fmt.Errorf("Failed to unmarshal rdf feed:\n%v\n", err)
+	}
+
+	feed := &RSSFeed{}
+	feed.Channel.Title = rdf.Channel.Title
+	feed.Channel.Link = rdf.Channel.Link
+	feed.Channel.Description = rdf.Channel.Description
+	feed.Channel.UpdatePeriod = rdf.Channel.UpdatePeriod
+	feed.Channel.UpdateFrequency = rdf.Channel.UpdateFrequency
+
+	for _, entry := range rdf.Items {
+		item := RSSItem{
+			Title:       entry.Title,
+			Link:        entry.Link,
+			Description: entry.Description,
+			PubDate:     entry.Date,
+			GUID:        entry.About,
+			Author:      entry.Creator,
+			Content:     entry.Content,
+		}
+		if item.Link == "" {
+			item.Link = entry.About
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+
+	return feed, nil
+}
@@ -1,48 +1,169 @@
 package rss
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"html"
 	"io"
 	"net/http"
-	"os"
+	"strings"
+	"time"
 )
 
-func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+// UserAgent is sent with every request. FetchTimeout bounds a single
+// request, including reading the body, zero means no limit.
+var (
+	UserAgent    = "gator"
+	FetchTimeout = 30 * time.Second
+)
+
+// ErrNotModified is returned by FetchFeed when the server answered a
+// conditional request with 304, meaning there are no new posts.
+var ErrNotModified = errors.New("feed not modified")
+
+// HTTPError is returned by FetchFeed when the server answers with a non-2xx
+// status code.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected response status: %s", e.Status)
+}
+
+// FetchFeed downloads and parses the feed at feedURL. The validators in cache
+// are sent as If-None-Match/If-Modified-Since, and the validators of the
+// response are returned so the caller can store them for the next fetch.
+func FetchFeed(ctx context.Context, feedURL string, cache CacheHeaders) (*RSSFeed, CacheHeaders, error) {
+	ctx, cancel := withFetchTimeout(ctx)
+	defer cancel()
+
 	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
 	if err != nil {
-		os.Exit(1)
-		return &RSSFeed{}, fmt.Errorf("Failed to create client:\n%v\n", err)
+		return &RSSFeed{}, cache, fmt.Errorf("Failed to create client:\n%v\n", err)
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	if cache.ETag != "" {
+		req.Header.Set("If-None-Match", cache.ETag)
+	}
+	if cache.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cache.LastModified)
 	}
-	req.Header.Set("User-Agent", "gator")
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		os.Exit(1)
-		return &RSSFeed{}, fmt.Errorf("Request failed:\n%v\n", err)
+		return &RSSFeed{}, cache, fmt.Errorf("Request failed:\n%v\n", err)
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == http.StatusNotModified {
+		return &RSSFeed{}, cache, ErrNotModified
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return &RSSFeed{}, cache, &HTTPError{StatusCode: res.StatusCode, Status: res.Status}
+	}
+
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return &RSSFeed{}, fmt.Errorf("Failed to read response body:\n%v\n", err)
+		return &RSSFeed{}, cache, fmt.Errorf("Failed to read response body:\n%v\n", err)
+	}
+
+	feed, err := parseFeed(body, res.Header.Get("Content-Type"))
+	if err != nil {
+		return &RSSFeed{}, cache, err
+	}
+
+	return feed, cacheHeaders(res.Header), nil
+}
+
+// cacheHeaders returns the validators of a response.
+func cacheHeaders(header http.Header) CacheHeaders {
+	return CacheHeaders{
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+}
+
+func withFetchTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if FetchTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, FetchTimeout)
+}
+
+// parseFeed sniffs the content type or root element of the document and
+// hands it to the matching decoder. Every format is normalised into an RSSFeed.
+func parseFeed(body []byte, contentType string) (*RSSFeed, error) {
+	if isJSONFeed(contentType, body) {
+		return parseJSONFeed(body)
 	}
 
-	var feed RSSFeed
-	err = xml.Unmarshal(body, &feed)
+	root, err := rootElement(body)
 	if err != nil {
 		return &RSSFeed{}, fmt.Errorf("Failed to unmarshal:\n%v\n", err)
 	}
 
-	cleanFeed := unescape(&feed)
+	switch root.Local {
+	case "feed":
+		return parseAtom(body)
+	case "RDF":
+		feed, err := parseRDF(body)
+		if err != nil {
+			return feed, err
+		}
+		return unescape(feed), nil
+	case "rss":
+		var feed RSSFeed
+		err = xml.Unmarshal(body, &feed)
+		if err != nil {
+			return &RSSFeed{}, fmt.Errorf("Failed to unmarshal:\n%v\n", err)
+		}
+		for _, link := range feed.Channel.Links {
+			if link = strings.TrimSpace(link); link != "" {
+				feed.Channel.Link = link
+				break
+			}
+		}
+		for i, item := range feed.Channel.Item {
+			// Plenty of RSS 2.0 feeds use Dublin Core instead of the core elements
+			if item.PubDate == "" {
+				feed.Channel.Item[i].PubDate = item.DCDate
+			}
+			if item.Author == "" {
+				feed.Channel.Item[i].Author = item.DCCreator
+			}
+		}
+		return unescape(&feed), nil
+	default:
+		return &RSSFeed{}, fmt.Errorf("Not a feed, document root is <%s>", root.Local)
+	}
+}
 
-	return cleanFeed, nil
+func rootElement(body []byte) (xml.Name, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.Name{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
 }
 
+// unescape decodes the entities RSS feeds often escape twice, as in
+// &amp;amp; or &amp;#8217;. Only the RSS formats need it, Atom and JSON
+// Feed text is decoded once as it should be, and decoding html content
+// again would turn escaped markup into real tags.
 func unescape(input *RSSFeed) *RSSFeed {
 	result := &RSSFeed{}
+	result.Channel = input.Channel
+	result.Channel.Item = nil
 
 	result.Channel.Title = html.UnescapeString(input.Channel.Title)
 	result.Channel.Link = html.UnescapeString(input.Channel.Link)
@@ -55,6 +176,9 @@ func unescape(input *RSSFeed) *RSSFeed {
 				Link:        html.UnescapeString(item.Link),
 				Description: html.UnescapeString(item.Description),
 				PubDate:     html.UnescapeString(item.PubDate),
+				GUID:        html.UnescapeString(item.GUID),
+				Author:      html.UnescapeString(item.Author),
+				Content:     html.UnescapeString(item.Content),
 			}
 			result.Channel.Item = append(result.Channel.Item, resultItem)
 		}
@@ -4,10 +4,24 @@ import "fmt"
 
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
+		Title string `xml:"title"`
+		Link  string `xml:"-"`
+		// Links holds every <link> of the channel, atom:link rel="self"
+		// included as it has the same local name. Link is the first one
+		// with text.
+		Links       []string  `xml:"link"`
 		Description string    `xml:"description"`
 		Item        []RSSItem `xml:"item"`
+		Image       struct {
+			URL string `xml:"url"`
+		} `xml:"image"`
+
+		// Publisher hints about how often the feed should be polled
+		TTL             string   `xml:"ttl"`
+		SkipHours       []string `xml:"skipHours>hour"`
+		SkipDays        []string `xml:"skipDays>day"`
+		UpdatePeriod    string   `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string   `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
 	} `xml:"channel"`
 }
 
@@ -16,6 +30,26 @@ type RSSItem struct {
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
+	GUID        string `xml:"guid"`
+	Author      string `xml:"author"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	DCDate      string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	DCCreator   string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+}
+
+// CacheHeaders holds the HTTP validators used for conditional requests.
+type CacheHeaders struct {
+	ETag         string
+	LastModified string
+}
+
+// FeedLink is a feed found by Discover. Feed and Cache hold the document
+// Discover downloaded to check it, so it needn't be fetched again.
+type FeedLink struct {
+	URL   string
+	Title string
+	Feed  *RSSFeed
+	Cache CacheHeaders
 }
 
 func (rf *RSSFeed) Display() {
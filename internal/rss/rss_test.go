@@ -0,0 +1,362 @@
+package rss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRSSChannelLink(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+	}{
+		{"atom:link after link", `<link>https://site.example/</link>
+<atom:link href="https://site.example/feed.xml" rel="self" type="application/rss+xml"/>`},
+		{"atom:link before link", `<atom:link href="https://site.example/feed.xml" rel="self" type="application/rss+xml"/>
+<link>https://site.example/</link>`},
+		{"whitespace around link", `<link>
+  https://site.example/
+</link>`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			body := `<?xml version="1.0"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel>
+<title>Site</title>` + c.body + `</channel></rss>`
+
+			feed, err := parseFeed([]byte(body), "application/rss+xml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if feed.Channel.Link != "https://site.example/" {
+				t.Errorf("channel link = %q, want https://site.example/", feed.Channel.Link)
+			}
+		})
+	}
+}
+
+func TestParseFeedFormats(t *testing.T) {
+	cases := []struct {
+		file        string
+		contentType string
+		title       string
+		link        string
+		description string
+		image       string
+		items       []RSSItem
+	}{
+		{
+			file:        "atom.xml",
+			contentType: "application/atom+xml",
+			title:       "Example Atom",
+			link:        "https://atom.example/",
+			description: "Posts about examples",
+			items: []RSSItem{
+				{Title: "Plain text", Link: "https://atom.example/plain", Description: "Just words & symbols",
+					PubDate: "2024-03-01T12:00:00Z", GUID: "https://atom.example/plain"},
+				{Title: "Escaped html", Link: "https://atom.example/html", Description: "<p>Hello <em>html</em></p>",
+					PubDate: "2024-03-01T10:00:00Z", GUID: "https://atom.example/html", Content: "<p>Hello <em>html</em></p>"},
+				{Title: "Inline xhtml", Link: "https://atom.example/xhtml", Description: "A <b>short</b> summary",
+					PubDate: "2024-03-01T09:00:00Z", GUID: "https://atom.example/xhtml", Content: "<p>Hello <em>xhtml</em></p>"},
+				{Title: "Prefixed xhtml", Link: "https://atom.example/prefixed", Description: "<xhtml:p>Prefixed</xhtml:p>",
+					PubDate: "2024-03-01T08:00:00Z", GUID: "https://atom.example/prefixed", Content: "<xhtml:p>Prefixed</xhtml:p>"},
+			},
+		},
+		{
+			file:        "feed.json",
+			contentType: "application/feed+json",
+			title:       "Example JSON Feed",
+			link:        "https://json.example/",
+			description: "Posts as json",
+			image:       "https://json.example/favicon.png",
+			items: []RSSItem{
+				{Title: "Html content", Link: "https://json.example/html", Description: "A summary",
+					PubDate: "2024-03-01T12:00:00Z", GUID: "1", Author: "Alice, Bob", Content: "<p>Hello <em>json</em></p>"},
+				{Title: "Text content", Description: "Just text",
+					PubDate: "2024-03-01T10:00:00Z", GUID: "https://json.example/text", Author: "Carol", Content: "Just text"},
+				{Title: "Linked", Link: "https://elsewhere.example/linked", Description: "Elsewhere",
+					GUID: "3", Author: "Feed Author", Content: "Elsewhere"},
+			},
+		},
+		{
+			file:        "rdf.xml",
+			contentType: "application/rdf+xml",
+			title:       "Example RSS 1.0",
+			link:        "https://rdf.example/",
+			description: "Posts as rdf",
+			items: []RSSItem{
+				{Title: "First", Link: "https://rdf.example/first", Description: "The first item",
+					PubDate: "2024-03-01T12:00:00Z", GUID: "https://rdf.example/first", Author: "Alice", Content: "<p>Hello <em>rdf</em></p>"},
+				{Title: "Second", Link: "https://rdf.example/second", Description: "No link, the about URL stands in",
+					PubDate: "2024-03-01", GUID: "https://rdf.example/second"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.file, func(t *testing.T) {
+			body, err := os.ReadFile(filepath.Join("testdata", c.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			feed, err := parseFeed(body, c.contentType)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			channel := feed.Channel
+			if channel.Title != c.title || channel.Link != c.link || channel.Description != c.description || channel.Image.URL != c.image {
+				t.Errorf("channel = %q, %q, %q, %q, want %q, %q, %q, %q",
+					channel.Title, channel.Link, channel.Description, channel.Image.URL, c.title, c.link, c.description, c.image)
+			}
+			if len(channel.Item) != len(c.items) {
+				t.Fatalf("got %v items, want %v", len(channel.Item), len(c.items))
+			}
+			for i, want := range c.items {
+				if got := channel.Item[i]; got != want {
+					t.Errorf("item %v = %+v\nwant %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSkipHours(t *testing.T) {
+	body := `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Site</title>
+<skipHours><hour>1</hour><hour>noon</hour><hour> 24 </hour><hour>-3</hour></skipHours>
+</channel></rss>`
+
+	// A malformed hour is skipped rather than failing the whole feed
+	feed, err := parseFeed([]byte(body), "application/rss+xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mask := feed.SkipHourMask(); mask != 1<<0|1<<1 {
+		t.Errorf("skip hour mask = %b, want hours 0 and 1", mask)
+	}
+}
+
+func TestMinInterval(t *testing.T) {
+	cases := []struct {
+		name      string
+		ttl       string
+		period    string
+		frequency string
+		want      time.Duration
+	}{
+		{"no hints", "", "", "", 0},
+		{"ttl in minutes", "60", "", "", time.Hour},
+		{"malformed ttl", "soon", "", "", 0},
+		{"update period", "", "daily", "", 24 * time.Hour},
+		{"update frequency", "", " Hourly ", "4", 15 * time.Minute},
+		{"malformed frequency", "", "weekly", "often", 7 * 24 * time.Hour},
+		{"longest hint wins", "120", "daily", "2", 12 * time.Hour},
+	}
+	for _, tc := range cases {
+		var feed RSSFeed
+		feed.Channel.TTL = tc.ttl
+		feed.Channel.UpdatePeriod = tc.period
+		feed.Channel.UpdateFrequency = tc.frequency
+		if got := feed.MinInterval(); got != tc.want {
+			t.Errorf("%v: MinInterval = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFetchFeedConditional(t *testing.T) {
+	const etag, lastModified = `"v1"`, "Mon, 12 Oct 2026 10:00:00 GMT"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>Site</title></channel></rss>`))
+	}))
+	defer srv.Close()
+
+	// The first fetch has no validators and gets the feed with new ones
+	feed, cache, err := FetchFeed(context.Background(), srv.URL, CacheHeaders{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Channel.Title != "Site" || cache != (CacheHeaders{ETag: etag, LastModified: lastModified}) {
+		t.Fatalf("first fetch = %q, %+v, want Site with the validators", feed.Channel.Title, cache)
+	}
+
+	// Sending them back gets a 304, and the validators are kept
+	_, again, err := FetchFeed(context.Background(), srv.URL, cache)
+	if !errors.Is(err, ErrNotModified) {
+		t.Fatalf("conditional fetch: err = %v, want ErrNotModified", err)
+	}
+	if again != cache {
+		t.Errorf("conditional fetch cache = %+v, want %+v", again, cache)
+	}
+}
+
+func TestAlternateFeedLinks(t *testing.T) {
+	base, err := url.Parse("https://site.example/blog/post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		head string
+		want []FeedLink
+	}{
+		{"absolute href", `<link rel="alternate" type="application/rss+xml" title="Posts" href="https://feeds.example/rss">`,
+			[]FeedLink{{URL: "https://feeds.example/rss", Title: "Posts"}}},
+		{"relative href", `<link rel="alternate" type="application/atom+xml" href="feed.xml">`,
+			[]FeedLink{{URL: "https://site.example/blog/feed.xml"}}},
+		{"root relative href", `<link rel="alternate" type="application/feed+json" href="/feed.json">`,
+			[]FeedLink{{URL: "https://site.example/feed.json"}}},
+		{"loose markup", `<LINK REL='Alternate home' TYPE=application/rss+xml HREF=/rss?a=1&amp;b=2 />`,
+			[]FeedLink{{URL: "https://site.example/rss?a=1&b=2"}}},
+		{"several feeds", `<link rel="alternate" type="application/rss+xml" title="Posts" href="/posts.xml">
+<link rel="stylesheet" href="/style.css">
+<link rel="alternate" type="application/atom+xml" title="Comments" href="/comments.xml">`,
+			[]FeedLink{{URL: "https://site.example/posts.xml", Title: "Posts"}, {URL: "https://site.example/comments.xml", Title: "Comments"}}},
+		{"not a feed", `<link rel="alternate" type="text/html" hreflang="de" href="/de/">
+<link rel="alternate" type="application/rss+xml">`,
+			[]FeedLink{}},
+	}
+	for _, tc := range cases {
+		got := alternateFeedLinks([]byte("<html><head>"+tc.head+"</head></html>"), base)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%v: alternateFeedLinks = %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	const rssBody = `<?xml version="1.0"?><rss version="2.0"><channel><title>%v</title></channel></rss>`
+	mux := http.NewServeMux()
+	serveFeed := func(path, title string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/rss+xml")
+			fmt.Fprintf(w, rssBody, title)
+		})
+	}
+	servePage := func(path, head string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, "<html><head>%v</head><body></body></html>", head)
+		})
+	}
+	serveFeed("/blog/posts.xml", "Posts")
+	serveFeed("/comments.xml", "Comments")
+	serveFeed("/feed.xml", "Probed")
+	servePage("/blog/", `<link rel="alternate" type="application/rss+xml" href="posts.xml">
+<link rel="alternate" type="application/rss+xml" title="All comments" href="/comments.xml">
+<link rel="alternate" type="application/rss+xml" title="Gone" href="/missing.xml">`)
+	servePage("/plain", "")
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	titles := func(links []FeedLink) []string {
+		got := []string{}
+		for _, link := range links {
+			got = append(got, link.Title+" "+strings.TrimPrefix(link.URL, srv.URL))
+		}
+		return got
+	}
+	cases := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{"feed URL", "/feed.xml", []string{"Probed /feed.xml"}},
+		// Links that don't lead to a feed are dropped
+		{"several advertised feeds", "/blog/", []string{"Posts /blog/posts.xml", "All comments /comments.xml"}},
+		{"common paths", "/plain", []string{"Probed /feed.xml"}},
+	}
+	for _, tc := range cases {
+		links, err := Discover(context.Background(), srv.URL+tc.path)
+		if err != nil {
+			t.Errorf("%v: %v", tc.name, err)
+			continue
+		}
+		if got := titles(links); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%v: Discover = %q, want %q", tc.name, got, tc.want)
+		}
+		for _, link := range links {
+			if link.Feed == nil {
+				t.Errorf("%v: %v has no feed", tc.name, link.URL)
+			}
+		}
+	}
+}
+
+func TestJSONFeedAuthors(t *testing.T) {
+	body := `{"version": "https://jsonfeed.org/version/1", "title": "Old",
+"author": {"name": "Feed Author"},
+"items": [
+  {"id": "1", "title": "No author"},
+  {"id": "2", "title": "Own author", "author": {"name": "Alice"}},
+  {"id": "3", "title": "Both fields", "authors": [{"name": "Bob"}], "author": {"name": "Bob"}}
+]}`
+
+	// The 1.0 feed author stands in for items without one
+	feed, err := parseFeed([]byte(body), "application/json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Feed Author", "Alice", "Bob"}
+	for i, item := range feed.Channel.Item {
+		if item.Author != want[i] {
+			t.Errorf("%v: author = %q, want %q", item.Title, item.Author, want[i])
+		}
+	}
+}
+
+func TestParseAtomDecodesOnce(t *testing.T) {
+	body := `<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom"><title>Tom &amp;amp; Jerry</title>
+<entry><id>urn:uuid:1</id><title>Only self</title><updated>2024-03-01T12:00:00Z</updated>
+<link rel="self" href="https://atom.example/entry.xml"/>
+<link rel="enclosure" href="https://atom.example/episode.mp3"/>
+<content type="html">&lt;p&gt;Use &amp;lt;code&amp;gt; tags&lt;/p&gt;</content></entry>
+<entry><id>urn:uuid:2</id><title>Xhtml</title><updated>2024-03-01T12:00:00Z</updated>
+<link rel="enclosure" href="https://atom.example/episode.mp3"/>
+<link href="https://atom.example/xhtml"/>
+<content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>1 &lt; 2 &amp; 3</p></div></content></entry>
+</feed>`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml")
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	feed, _, err := FetchFeed(context.Background(), srv.URL, CacheHeaders{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feed.Channel.Title != "Tom &amp; Jerry" {
+		t.Errorf("title = %q, want the text as decoded by the xml parser", feed.Channel.Title)
+	}
+	want := []RSSItem{
+		// Neither self nor an enclosure is the entry's page, the id stands in
+		{Link: "urn:uuid:1", Content: "<p>Use &lt;code&gt; tags</p>"},
+		{Link: "https://atom.example/xhtml", Content: "<p>1 &lt; 2 &amp; 3</p>"},
+	}
+	for i, item := range feed.Channel.Item {
+		if item.Link != want[i].Link || item.Content != want[i].Content {
+			t.Errorf("%v: link %q, content %q, want %q, %q", item.Title, item.Link, item.Content, want[i].Link, want[i].Content)
+		}
+	}
+}
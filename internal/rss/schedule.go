@@ -0,0 +1,74 @@
+package rss
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syndicationPeriods maps sy:updatePeriod values to their length.
+var syndicationPeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+// MinInterval is the shortest polling interval the publisher asks for, taken
+// from <ttl> and sy:updatePeriod/sy:updateFrequency. It returns 0 when the
+// feed gives no hint.
+func (rf *RSSFeed) MinInterval() time.Duration {
+	var interval time.Duration
+
+	ttl, err := strconv.Atoi(strings.TrimSpace(rf.Channel.TTL))
+	if err == nil && ttl > 0 {
+		interval = time.Duration(ttl) * time.Minute
+	}
+
+	period, ok := syndicationPeriods[strings.ToLower(strings.TrimSpace(rf.Channel.UpdatePeriod))]
+	if ok {
+		frequency, err := strconv.Atoi(strings.TrimSpace(rf.Channel.UpdateFrequency))
+		if err != nil || frequency < 1 {
+			// sy:updateFrequency defaults to 1
+			frequency = 1
+		}
+		interval = max(interval, period/time.Duration(frequency))
+	}
+
+	return interval
+}
+
+// SkipHourMask returns <skipHours> as a bit mask, bit n set meaning the feed
+// should not be polled during hour n GMT. Hours that aren't a number are
+// ignored, like unknown days in SkipDayMask.
+func (rf *RSSFeed) SkipHourMask() int32 {
+	var mask int32
+	for _, value := range rf.Channel.SkipHours {
+		hour, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		// RSS 2.0 allows 0-23, some publishers use 24 for midnight
+		if hour == 24 {
+			hour = 0
+		}
+		if hour >= 0 && hour < 24 {
+			mask |= 1 << hour
+		}
+	}
+	return mask
+}
+
+// SkipDayMask returns <skipDays> as a bit mask indexed by time.Weekday.
+func (rf *RSSFeed) SkipDayMask() int32 {
+	var mask int32
+	for _, day := range rf.Channel.SkipDays {
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			if strings.EqualFold(strings.TrimSpace(day), weekday.String()) {
+				mask |= 1 << weekday
+			}
+		}
+	}
+	return mask
+}
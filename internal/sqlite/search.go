@@ -0,0 +1,74 @@
+package sqlite
+
+import "strings"
+
+// matchQuery rewrites a query in the web search syntax of Postgres'
+// websearch_to_tsquery into an FTS5 query: words and "quoted phrases" must
+// all match, -term excludes a term and "or" separates alternatives. Every
+// term is quoted, so punctuation in it can't be read as FTS5 syntax.
+//
+// FTS5 can only exclude terms from a match, an alternative of only
+// excluded terms is left out.
+func matchQuery(query string) string {
+	type term struct {
+		text    string
+		negated bool
+	}
+
+	groups := [][]term{{}}
+	for query = strings.TrimSpace(query); query != ""; query = strings.TrimSpace(query) {
+		negated := false
+		if strings.HasPrefix(query, "-") {
+			negated, query = true, query[1:]
+		}
+
+		var text string
+		quoted := strings.HasPrefix(query, `"`)
+		if quoted {
+			end := strings.Index(query[1:], `"`)
+			if end < 0 {
+				end = len(query) - 1
+			}
+			text, query = query[1:end+1], query[min(end+2, len(query)):]
+		} else {
+			end := strings.IndexAny(query, " \t\n")
+			if end < 0 {
+				end = len(query)
+			}
+			text, query = query[:end], query[end:]
+		}
+
+		text = strings.TrimSpace(text)
+		switch {
+		case text == "":
+		case strings.EqualFold(text, "or") && !quoted && !negated:
+			groups = append(groups, []term{})
+		default:
+			last := len(groups) - 1
+			groups[last] = append(groups[last], term{text: text, negated: negated})
+		}
+	}
+
+	alternatives := []string{}
+	for _, group := range groups {
+		var required, excluded []string
+		for _, term := range group {
+			quotedText := `"` + strings.ReplaceAll(term.text, `"`, `""`) + `"`
+			if term.negated {
+				excluded = append(excluded, quotedText)
+			} else {
+				required = append(required, quotedText)
+			}
+		}
+		if len(required) == 0 {
+			continue
+		}
+
+		alternative := strings.Join(required, " AND ")
+		for _, text := range excluded {
+			alternative += " NOT " + text
+		}
+		alternatives = append(alternatives, "("+alternative+")")
+	}
+	return strings.Join(alternatives, " OR ")
+}
@@ -0,0 +1,189 @@
+// Package sqlite stores gator in a single SQLite file, for installs that
+// don't want to run a Postgres server. It uses the pure Go driver, so the
+// binary still builds without cgo.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wfcornelissen/blogag/internal/database"
+	_ "modernc.org/sqlite"
+)
+
+var _ database.DBTX = (*DB)(nil)
+
+// ParseURL returns the path of a sqlite:// db_url, and whether dbURL is
+// one. A leading ~/ is expanded to the home directory.
+func ParseURL(dbURL string) (string, bool) {
+	file, ok := strings.CutPrefix(dbURL, Scheme)
+	if !ok {
+		return "", false
+	}
+	if rest, found := strings.CutPrefix(file, "~/"); found {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			file = filepath.Join(home, rest)
+		}
+	}
+	return file, true
+}
+
+// Open opens the database at file, creating it and its directory when
+// missing.
+func Open(file string) (*sql.DB, error) {
+	if file == "" {
+		return nil, fmt.Errorf("No path given for the SQLite database")
+	}
+	err := os.MkdirAll(filepath.Dir(file), 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create directory for database:\n%v\n", err)
+	}
+
+	// Times are stored as text in UTC, see convertArgs
+	dsn := "file:" + file + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+	conn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite has a single writer, one connection keeps the aggregator's
+	// workers from failing with SQLITE_BUSY
+	conn.SetMaxOpenConns(1)
+
+	err = conn.Ping()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// New loads the queries in the .sql files of dir in fsys, in the sqlc
+// format, to run on conn in place of the Postgres ones.
+func New(conn *sql.DB, fsys fs.FS, dir string) (*DB, error) {
+	names, err := fs.Glob(fsys, path.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+
+	queries := map[string]string{}
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read queries %v:\n%v\n", name, err)
+		}
+		for _, query := range strings.Split(string(data), "-- name: ")[1:] {
+			queryName, _, _ := strings.Cut(query, " ")
+			if _, exists := queries[queryName]; exists {
+				return nil, fmt.Errorf("Query %v is defined twice", queryName)
+			}
+			queries[queryName] = "-- name: " + strings.TrimSpace(query)
+		}
+	}
+	return &DB{conn: conn, queries: queries}, nil
+}
+
+// WithTx returns a DB that runs the queries in tx.
+func (db *DB) WithTx(tx *sql.Tx) *DB {
+	return &DB{conn: tx, queries: db.queries}
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	query, args, err := db.translate(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return db.conn.ExecContext(ctx, query, args...)
+}
+
+func (db *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	query, _, err := db.translate(query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return db.conn.PrepareContext(ctx, query)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	query, args, err := db.translate(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return db.conn.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext can't hand back the error of a query without a SQLite
+// version, a *sql.Row only gets one from running. Instead it runs a
+// statement that fails with the error, running the Postgres query could
+// quietly do the wrong thing.
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	query, args, err := db.translate(query, args)
+	if err != nil {
+		return db.conn.QueryRowContext(ctx, failWith(err))
+	}
+	return db.conn.QueryRowContext(ctx, query, args...)
+}
+
+// failWith returns a statement that fails with err in its message, by
+// selecting from a table named after it.
+func failWith(err error) string {
+	name := strings.ReplaceAll(strings.TrimSpace(err.Error()), `"`, `""`)
+	return `SELECT * FROM "` + name + `"`
+}
+
+// noRows stands in for a search that can't match anything, FTS5 fails on
+// an empty MATCH.
+const noRows = "SELECT NULL WHERE 0"
+
+// translate swaps a generated query, named on its first line, for the
+// SQLite version. The parameters keep the numbers sqlc gave them, so the
+// arguments are passed on as they are apart from times and search
+// queries. A query without a SQLite version is an error.
+func (db *DB) translate(query string, args []interface{}) (string, []interface{}, error) {
+	header, _, _ := strings.Cut(query, "\n")
+	name, found := strings.CutPrefix(header, "-- name: ")
+	if !found {
+		return "", nil, fmt.Errorf("Query without a name can't run on SQLite:\n%v\n", header)
+	}
+	name, _, _ = strings.Cut(name, " ")
+	translated, exists := db.queries[name]
+	if !exists {
+		return "", nil, fmt.Errorf("Query %v has no SQLite version", name)
+	}
+
+	args = convertArgs(args)
+	if name == "SearchPosts" && len(args) > 0 {
+		if search, ok := args[0].(string); ok {
+			args[0] = matchQuery(search)
+			// Only excluded terms, or none at all, match no posts
+			if args[0] == "" {
+				return noRows, nil, nil
+			}
+		}
+	}
+	return translated, args, nil
+}
+
+// convertArgs moves times to UTC. SQLite compares the stored text, which
+// only sorts in time order when every time has the same offset.
+func convertArgs(args []interface{}) []interface{} {
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch value := arg.(type) {
+		case time.Time:
+			converted[i] = value.UTC()
+		case sql.NullTime:
+			converted[i] = sql.NullTime{Time: value.Time.UTC(), Valid: value.Valid}
+		default:
+			converted[i] = arg
+		}
+	}
+	return converted
+}
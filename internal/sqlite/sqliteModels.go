@@ -0,0 +1,15 @@
+package sqlite
+
+import "github.com/wfcornelissen/blogag/internal/database"
+
+// Scheme starts the db_url of a SQLite database, followed by its path.
+const Scheme = "sqlite://"
+
+// DB runs the queries sqlc generated for Postgres against SQLite. Each
+// query is swapped for the one of the same name in sql/sqlite/queries, so
+// database.New can use it like the Postgres pool.
+type DB struct {
+	// conn is the pool, or a transaction on it, see WithTx
+	conn    database.DBTX
+	queries map[string]string
+}
@@ -0,0 +1,343 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wfcornelissen/blogag/internal/database"
+	"github.com/wfcornelissen/blogag/internal/migrate"
+)
+
+// root holds the sql directory of the repository.
+var root = os.DirFS("../..")
+
+func openTest(t *testing.T) (*sql.DB, migrate.Schema) {
+	t.Helper()
+	conn, err := Open(filepath.Join(t.TempDir(), "data", "gator.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	migrations, err := migrate.Load(root, "sql/sqlite/schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, migrate.Schema{Dialect: migrate.SQLite, Migrations: migrations}
+}
+
+func TestParseURL(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		url  string
+		path string
+		ok   bool
+	}{
+		{"sqlite:///var/lib/gator.db", "/var/lib/gator.db", true},
+		{"sqlite://gator.db", "gator.db", true},
+		{"sqlite://~/gator/gator.db", filepath.Join(home, "gator/gator.db"), true},
+		{"postgres://localhost/gator", "", false},
+	}
+	for _, test := range tests {
+		path, ok := ParseURL(test.url)
+		if path != test.path || ok != test.ok {
+			t.Errorf("ParseURL(%q) = %q, %v, want %q, %v", test.url, path, ok, test.path, test.ok)
+		}
+	}
+}
+
+func TestQueriesMatchPostgres(t *testing.T) {
+	db, err := New(nil, root, "sql/sqlite/queries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	postgres, err := New(nil, root, "sql/queries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postgres.queries) == 0 {
+		t.Fatal("no Postgres queries found in sql/queries")
+	}
+
+	for name := range postgres.queries {
+		if _, exists := db.queries[name]; !exists {
+			t.Errorf("query %v has no SQLite version", name)
+		}
+	}
+	for name := range db.queries {
+		if _, exists := postgres.queries[name]; !exists {
+			t.Errorf("SQLite query %v has no Postgres version", name)
+		}
+	}
+
+	// The parameters are passed in the order sqlc numbered them for
+	// Postgres, each query must use the same ones
+	param := regexp.MustCompile(`[$?]\d+|sqlc\.n?arg\(\w+\)`)
+	for name, query := range postgres.queries {
+		named := map[string]bool{}
+		for _, match := range param.FindAllString(query, -1) {
+			named[match] = true
+		}
+		numbered := map[string]bool{}
+		for _, match := range param.FindAllString(db.queries[name], -1) {
+			numbered[match] = true
+		}
+		if len(named) != len(numbered) {
+			t.Errorf("query %v takes %v parameters for Postgres and %v for SQLite", name, len(named), len(numbered))
+		}
+	}
+}
+
+func TestUnknownQuery(t *testing.T) {
+	conn, _ := openTest(t)
+	db, err := New(conn, root, "sql/sqlite/queries")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const query = "-- name: NoSuchQuery :one\nSELECT 1\n"
+	var n int
+	err = db.QueryRowContext(context.Background(), query).Scan(&n)
+	if err == nil || !strings.Contains(err.Error(), "NoSuchQuery has no SQLite version") {
+		t.Errorf("QueryRowContext of an unknown query: err = %v, want no SQLite version", err)
+	}
+	_, err = db.ExecContext(context.Background(), query)
+	if err == nil {
+		t.Error("ExecContext of an unknown query succeeded")
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	conn, schema := openTest(t)
+	ctx := context.Background()
+
+	applied, err := migrate.Up(ctx, conn, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(schema.Migrations) {
+		t.Fatalf("applied %v migrations, want %v", len(applied), len(schema.Migrations))
+	}
+
+	for range schema.Migrations {
+		_, err := migrate.Down(ctx, conn, schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	version, err := migrate.Version(ctx, conn, schema.Dialect)
+	if err != nil || version != 0 {
+		t.Fatalf("version after rolling back = %v, %v, want 0", version, err)
+	}
+}
+
+func TestMatchQuery(t *testing.T) {
+	tests := []struct {
+		search string
+		match  string
+	}{
+		{"", ""},
+		{"golang", `("golang")`},
+		{"go generics", `("go" AND "generics")`},
+		{`"type parameters" go`, `("type parameters" AND "go")`},
+		{"go -java", `("go" NOT "java")`},
+		{"go or rust", `("go") OR ("rust")`},
+		{"-java", ""},
+		{`say "hi`, `("say" AND "hi")`},
+		{`a"b`, `("a""b")`},
+		{"c++ OR c#", `("c++") OR ("c#")`},
+	}
+	for _, test := range tests {
+		match := matchQuery(test.search)
+		if match != test.match {
+			t.Errorf("matchQuery(%q) = %q, want %q", test.search, match, test.match)
+		}
+	}
+}
+
+func TestQueries(t *testing.T) {
+	conn, schema := openTest(t)
+	ctx := context.Background()
+	_, err := migrate.Up(ctx, conn, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := New(conn, root, "sql/sqlite/queries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := database.New(db)
+
+	// Times in another zone are stored in UTC, and still compare in order
+	zone := time.FixedZone("UTC+5", 5*60*60)
+	now := time.Now().In(zone).Truncate(time.Second)
+	at := func(d time.Duration) sql.NullTime { return sql.NullTime{Time: now.Add(d), Valid: true} }
+
+	user, err := q.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), CreatedAt: at(0), UpdatedAt: at(0), Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.CreatedAt.Time.Equal(now) {
+		t.Errorf("created at = %v, want %v", user.CreatedAt.Time, now)
+	}
+
+	feeds := []database.Feed{}
+	for _, name := range []string{"First", "Second", "Third"} {
+		feed, err := q.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: at(0),
+			UpdatedAt: at(0),
+			Name:      sql.NullString{String: name, Valid: true},
+			Url:       sql.NullString{String: "https://example.com/" + name, Valid: true},
+			UserID:    user.ID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		feeds = append(feeds, feed)
+	}
+
+	// Feeds never fetched come first, then the ones due the longest
+	err = q.SetFeedNextFetchAt(ctx, database.SetFeedNextFetchAtParams{NextFetchAt: at(-time.Hour), ID: feeds[0].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = q.SetFeedNextFetchAt(ctx, database.SetFeedNextFetchAtParams{NextFetchAt: at(time.Hour), ID: feeds[1].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimed, err := q.ClaimFeedsToFetch(ctx, database.ClaimFeedsToFetchParams{Now: at(0), BatchSize: 10, ClaimedUntil: at(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 2 {
+		t.Fatalf("claimed %v feeds, want First and Third", len(claimed))
+	}
+	next, err := q.GetNextFeedToFetch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Name.String == "Second" {
+		t.Errorf("next feed to fetch is Second, which is due last")
+	}
+
+	_, err = q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{ID: uuid.New(), UserID: user.ID, FeedID: feeds[0].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts := []struct {
+		title   string
+		content string
+	}{
+		{"Running SQLite", "An embedded database in a single file"},
+		{"Postgres in production", "A server for many users"},
+	}
+	postIDs := []uuid.UUID{}
+	for i, post := range posts {
+		id := uuid.New()
+		inserted, err := q.CreatePost(ctx, database.CreatePostParams{
+			ID:          id,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Title:       post.title,
+			Url:         "https://example.com/post/" + post.title,
+			PublishedAt: at(-time.Duration(i+1) * time.Hour),
+			FeedID:      uuid.NullUUID{UUID: feeds[0].ID, Valid: true},
+			Content:     sql.NullString{String: post.content, Valid: true},
+		})
+		if err != nil || inserted != 1 {
+			t.Fatalf("CreatePost = %v, %v, want 1 post inserted", inserted, err)
+		}
+		postIDs = append(postIDs, id)
+	}
+
+	// A post already stored under the URL is skipped without an error
+	inserted, err := q.CreatePost(ctx, database.CreatePostParams{
+		ID:     uuid.New(),
+		Title:  "Running SQLite again",
+		Url:    "https://example.com/post/Running SQLite",
+		FeedID: uuid.NullUUID{UUID: feeds[0].ID, Valid: true},
+	})
+	if err != nil || inserted != 0 {
+		t.Errorf("CreatePost for a stored URL = %v, %v, want no post inserted", inserted, err)
+	}
+
+	// Words are stemmed, so "runs" finds "Running"
+	results, err := q.SearchPosts(ctx, database.SearchPostsParams{Query: "runs -postgres", UserID: user.ID, MaxResults: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ID != postIDs[0] || results[0].Rank <= 0 || results[0].FeedName.String != "First" {
+		t.Fatalf("search results = %+v, want Running SQLite", results)
+	}
+
+	// FTS5 rejects an empty MATCH, a search excluding every term finds nothing
+	results, err = q.SearchPosts(ctx, database.SearchPostsParams{Query: "-postgres", UserID: user.ID, MaxResults: 10})
+	if err != nil || len(results) != 0 {
+		t.Errorf("search for -postgres = %+v, %v, want no results", results, err)
+	}
+
+	// A query that only exists for Postgres is an error, not run as is
+	_, err = db.ExecContext(ctx, "-- name: Missing :exec\nSELECT 1")
+	if err == nil {
+		t.Error("query without a SQLite version ran")
+	}
+
+	read, err := q.MarkPostsReadBefore(ctx, database.MarkPostsReadBeforeParams{ReadAt: now, UserID: user.ID, Before: now.Add(-90 * time.Minute)})
+	if err != nil || read != 1 {
+		t.Errorf("MarkPostsReadBefore = %v, %v, want 1 post read", read, err)
+	}
+	read, err = q.MarkFeedRead(ctx, database.MarkFeedReadParams{UserID: user.ID, ReadAt: now, FeedID: uuid.NullUUID{UUID: feeds[0].ID, Valid: true}})
+	if err != nil || read != 1 {
+		t.Errorf("MarkFeedRead = %v, %v, want the 1 unread post read", read, err)
+	}
+	unread, err := q.MarkFeedUnread(ctx, database.MarkFeedUnreadParams{UserID: user.ID, FeedID: uuid.NullUUID{UUID: feeds[0].ID, Valid: true}})
+	if err != nil || unread != 2 {
+		t.Errorf("MarkFeedUnread = %v, %v, want 2 posts unread", unread, err)
+	}
+
+	// Starring again updates the note of the star, the post is kept with
+	// its content
+	for _, note := range []string{"first", "second"} {
+		_, err := q.CreateStar(ctx, database.CreateStarParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			UserID:    user.ID,
+			Note:      sql.NullString{String: note, Valid: true},
+			PostID:    postIDs[1],
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	stars, err := q.GetStarsForUser(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stars) != 1 || stars[0].Note.String != "second" || stars[0].FeedName.String != "First" || stars[0].Content.String != "A server for many users" {
+		t.Errorf("stars = %+v, want one noted second", stars)
+	}
+
+	err = q.ResetDatabase(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"users", "feeds", "feed_follows", "posts", "post_reads", "stars", "posts_search"} {
+		var count int
+		err := conn.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count)
+		if err != nil || count != 0 {
+			t.Errorf("%v has %v rows after reset, %v", table, count, err)
+		}
+	}
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"embed"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -12,9 +17,24 @@ import (
 	"github.com/wfcornelissen/blogag/internal/database"
 	"github.com/wfcornelissen/blogag/internal/handling"
 	"github.com/wfcornelissen/blogag/internal/middleware"
+	"github.com/wfcornelissen/blogag/internal/migrate"
+	"github.com/wfcornelissen/blogag/internal/rss"
+	"github.com/wfcornelissen/blogag/internal/sqlite"
 )
 
+// sqlFS holds the migrations, so the binary can set up its own database,
+// and the queries for SQLite
+//
+//go:embed sql/schema/*.sql sql/sqlite/schema/*.sql sql/sqlite/queries/*.sql
+var sqlFS embed.FS
+
 func main() {
+	os.Exit(run())
+}
+
+// run executes the command line and returns the exit code, so deferred
+// cleanup happens before the process exits.
+func run() int {
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
 		// .env file is optional, so we only log if there's an error other than "file not found"
@@ -23,77 +43,186 @@ func main() {
 		}
 	}
 
-	dbString := os.Getenv("GOOSE_DBSTRING")
-	if dbString == "" {
-		fmt.Println("Error: GOOSE_DBSTRING environment variable is not set")
-		os.Exit(1)
-		return
+	cmds := handling.Commands{
+		Commands: make(map[string]handling.CommandSpec),
 	}
 
-	db, err := sql.Open("postgres", dbString)
+	cmds.Register(handling.HelpCommand, cmds.HandlerHelp)
+	cmds.Register(handling.CompletionCommand, handling.HandlerCompletion)
+	cmds.Register(handling.CompleteCommand, cmds.HandlerComplete)
+	cmds.Register(handling.ShellCommand, cmds.HandlerShell)
+	cmds.Register(handling.MigrateCommand, handling.HandlerMigrate)
+	cmds.Register(handling.ConfigCommand, handling.HandlerConfig)
+	cmds.Register(handling.ProfileCommand, handling.HandlerProfile)
+	cmds.Register(handling.LoginCommand, handling.HandlerLogin)
+	cmds.Register(handling.RegisterCommand, handling.HandlerRegister)
+	cmds.Register(handling.ResetCommand, handling.HandlerReset)
+	cmds.Register(handling.UsersCommand, handling.HandlerUsers)
+	cmds.Register(handling.AggCommand, handling.HandlerAgg)
+	cmds.Register(handling.FeedStatusCommand, handling.HandlerFeedStatus)
+	cmds.Register(handling.AddFeedCommand, middleware.MiddlewareLoggedIn(handling.HandlerAddFeed))
+	cmds.Register(handling.FeedsCommand, handling.HandlerFeeds)
+	cmds.Register(handling.FollowCommand, middleware.MiddlewareLoggedIn(handling.HandlerFollow))
+	cmds.Register(handling.FollowingCommand, middleware.MiddlewareLoggedIn(handling.HandlerFollowing))
+	cmds.Register(handling.UnfollowCommand, middleware.MiddlewareLoggedIn(handling.HandlerUnfollow))
+	cmds.Register(handling.BrowseCommand, middleware.MiddlewareLoggedIn(handling.HandlerBrowse))
+	cmds.Register(handling.ReadCommand, middleware.MiddlewareLoggedIn(handling.HandlerRead))
+	cmds.Register(handling.UnreadCommand, middleware.MiddlewareLoggedIn(handling.HandlerUnread))
+	cmds.Register(handling.StarCommand, middleware.MiddlewareLoggedIn(handling.HandlerStar))
+	cmds.Register(handling.UnstarCommand, middleware.MiddlewareLoggedIn(handling.HandlerUnstar))
+	cmds.Register(handling.StarredCommand, middleware.MiddlewareLoggedIn(handling.HandlerStarred))
+	cmds.Register(handling.SearchCommand, middleware.MiddlewareLoggedIn(handling.HandlerSearch))
+	cmds.Register(handling.ImportOPMLCommand, middleware.MiddlewareLoggedIn(handling.HandlerImportOPML))
+	cmds.Register(handling.ExportOPMLCommand, middleware.MiddlewareLoggedIn(handling.HandlerExportOPML))
+
+	input, err := handling.ParseCommand(os.Args[1:]) // Skip program name
 	if err != nil {
-		fmt.Printf("Error opening database: %v\n", err)
-		os.Exit(1)
-		return
+		if len(os.Args) < 2 {
+			cmds.PrintCommands(os.Stderr)
+		} else {
+			handling.PrintError(os.Stderr, err)
+		}
+		return handling.ExitUsage
 	}
-	defer db.Close()
 
-	// Test the connection
-	if err := db.Ping(); err != nil {
-		fmt.Printf("Error connecting to database: %v\n", err)
-		os.Exit(1)
-		return
+	newCommand, err := cmds.Parse(input)
+	if err == flag.ErrHelp {
+		return handling.ExitOK
+	}
+	if err != nil {
+		handling.PrintError(os.Stderr, err)
+		return handling.ExitCode(err)
 	}
 
-	dbQueries := database.New(db)
+	// Cancelled on Ctrl-C or SIGTERM so long running commands such as agg
+	// can stop cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	cfg, err := config.Read()
+	cfg, err := loadConfig(newCommand.Config, newCommand.Profile)
 	if err != nil {
-		fmt.Println("Error fetching original cfg")
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
+		return handling.ExitFailure
+	}
+
+	newState := config.State{State: &cfg}
+	if spec, _ := cmds.Spec(newCommand.Name); !spec.Offline {
+		dbState, err := openDatabase(cfg.DbUrl)
+		if err != nil && !spec.OptionalDB {
+			fmt.Fprintln(os.Stderr, err)
+			return handling.ExitFailure
+		}
+		if err == nil {
+			defer dbState.Conn.Close()
+			newState.Db = dbState.Db
+			newState.Conn = dbState.Conn
+			newState.Schema = dbState.Schema
+			newState.WithTx = dbState.WithTx
+		}
 
-		return
+		if err == nil && !spec.AnySchema {
+			err = handling.CheckSchema(ctx, &newState)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return handling.ExitFailure
+			}
+		}
 	}
 
-	cmds := handling.Commands{
-		Commands: make(map[string]func(*config.State, handling.Command) error),
-	}
-
-	cmds.Register("login", handling.HandlerLogin)
-	cmds.Register("register", handling.HandlerRegister)
-	cmds.Register("reset", handling.HandlerReset)
-	cmds.Register("users", handling.HandlerUsers)
-	cmds.Register("agg", handling.HandlerAgg)
-	cmds.Register("addfeed", middleware.MiddlewareLoggedIn(handling.HandlerAddFeed))
-	cmds.Register("feeds", handling.HandlerFeeds)
-	cmds.Register("follow", middleware.MiddlewareLoggedIn(handling.HandlerFollow))
-	cmds.Register("following", middleware.MiddlewareLoggedIn(handling.HandlerFollowing))
-	cmds.Register("unfollow", middleware.MiddlewareLoggedIn(handling.HandlerUnfollow))
-	cmds.Register("browse", middleware.MiddlewareLoggedIn(handling.HandlerBrowse))
-
-	var newCommand handling.Command
-	input := os.Args[1:] // Skip program name
-	switch len(input) {
-	case 0:
-		fmt.Println("Too few arguements")
-		os.Exit(1)
-		return
-	case 1:
-		newCommand = handling.Command{
-			Name: input[0],
+	err = cmds.Exec(ctx, &newState, newCommand)
+	if err != nil {
+		handling.PrintError(os.Stderr, err)
+	}
+	return handling.ExitCode(err)
+}
+
+// loadConfig reads the config file and profile, --config and --profile when
+// given, and applies the fetch settings to the rss package. A file named
+// with --config has to exist, a mistyped path would silently read as empty.
+func loadConfig(override string, profile string) (config.Config, error) {
+	path, err := config.Path(override)
+	if err != nil {
+		return config.Config{}, err
+	}
+	if override != "" {
+		_, err := os.Stat(override)
+		if os.IsNotExist(err) {
+			return config.Config{}, fmt.Errorf("Error: config file %v does not exist", override)
 		}
-	default:
-		newCommand = handling.Command{
-			Name: input[0],
-			Args: input[1:],
+		if err != nil {
+			return config.Config{}, fmt.Errorf("Error reading config file %v:\n%v\n", override, err)
 		}
 	}
+	cfg, err := config.Load(path, profile)
+	if err != nil {
+		return config.Config{}, err
+	}
+
+	rss.UserAgent = cfg.Agg.UserAgent
+	rss.FetchTimeout = cfg.Agg.FetchTimeout.Duration
+	return cfg, nil
+}
+
+// openDatabase connects to db_url, from the environment or the config file.
+// A sqlite:// URL opens a SQLite file, anything else is passed to Postgres.
+// Only the database fields of the returned state are set.
+func openDatabase(dbURL string) (config.State, error) {
+	if dbURL == "" {
+		return config.State{}, fmt.Errorf("Error: no database configured, run 'gator config set db_url <url>' or set GATOR_DB_URL")
+	}
+
+	if path, ok := sqlite.ParseURL(dbURL); ok {
+		return openSQLite(path)
+	}
+
+	migrations, err := migrate.Load(sqlFS, "sql/schema")
+	if err != nil {
+		return config.State{}, err
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return config.State{}, fmt.Errorf("Error opening database: %v", err)
+	}
+
+	// Test the connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return config.State{}, fmt.Errorf("Error connecting to database: %v", err)
+	}
+
+	queries := database.New(db)
+	return config.State{
+		Db:     queries,
+		Conn:   db,
+		Schema: migrate.Schema{Dialect: migrate.Postgres, Migrations: migrations},
+		WithTx: func(tx *sql.Tx) database.Querier { return queries.WithTx(tx) },
+	}, nil
+}
+
+// openSQLite opens the SQLite database at path, running the generated
+// queries through their SQLite versions.
+func openSQLite(path string) (config.State, error) {
+	migrations, err := migrate.Load(sqlFS, "sql/sqlite/schema")
+	if err != nil {
+		return config.State{}, err
+	}
+
+	db, err := sqlite.Open(path)
+	if err != nil {
+		return config.State{}, fmt.Errorf("Error opening database: %v", err)
+	}
 
-	newState := config.State{Db: dbQueries, State: &cfg}
-	err = cmds.Run(&newState, newCommand)
+	queries, err := sqlite.New(db, sqlFS, "sql/sqlite/queries")
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		db.Close()
+		return config.State{}, err
 	}
 
+	return config.State{
+		Db:     database.New(queries),
+		Conn:   db,
+		Schema: migrate.Schema{Dialect: migrate.SQLite, Migrations: migrations},
+		WithTx: func(tx *sql.Tx) database.Querier { return database.New(queries.WithTx(tx)) },
+	}, nil
 }
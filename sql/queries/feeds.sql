@@ -1,12 +1,15 @@
 -- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, description, site_url, icon_url)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8,
+    $9
 )
 RETURNING *;
 
@@ -16,10 +19,56 @@ SELECT name, url, user_id FROM feeds;
 -- name: GetFeedByURL :one
 SELECT * from feeds WHERE url = $1;
 
+-- name: GetFeedByName :one
+SELECT * from feeds WHERE name = $1;
+
 -- name: MarkFeedFetched :exec
 UPDATE feeds SET last_fetched_at = $1 WHERE url = $2;
 
 -- name: GetNextFeedToFetch :one
 SELECT * FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
+ORDER BY next_fetch_at ASC NULLS FIRST
 LIMIT 1;
+
+-- name: UpdateFeedCacheHeaders :exec
+UPDATE feeds SET etag = $1, last_modified = $2 WHERE id = $3;
+
+-- name: ClaimFeedsToFetch :many
+WITH claimed AS (
+    SELECT id FROM feeds
+    WHERE next_fetch_at IS NULL OR next_fetch_at <= sqlc.arg(now)
+    ORDER BY next_fetch_at ASC NULLS FIRST
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE feeds SET last_fetched_at = sqlc.arg(now), next_fetch_at = sqlc.arg(claimed_until)
+FROM claimed
+WHERE feeds.id = claimed.id
+RETURNING feeds.*;
+
+-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET last_error = $1,
+    last_error_status = $2,
+    last_error_at = $3,
+    consecutive_failures = consecutive_failures + 1
+WHERE id = $4;
+
+-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET last_error = NULL,
+    last_error_status = NULL,
+    consecutive_failures = 0
+WHERE id = $1;
+
+-- name: GetFailingFeeds :many
+SELECT name, url, last_fetched_at, last_error, last_error_status, last_error_at, consecutive_failures
+FROM feeds
+WHERE consecutive_failures > 0
+ORDER BY consecutive_failures DESC, name ASC;
+
+-- name: SetFeedNextFetchAt :exec
+UPDATE feeds SET next_fetch_at = $1 WHERE id = $2;
+
+-- name: UpdateFeedScheduleHints :exec
+UPDATE feeds SET min_fetch_interval = $1, skip_hours = $2, skip_days = $3 WHERE id = $4;
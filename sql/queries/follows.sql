@@ -23,7 +23,16 @@ INNER JOIN users ON inserted_feed_follow.user_id = users.id;
 SELECT
     feed_follows.*,
     feeds.name AS feed_name,
-    users.name AS user_name
+    feeds.url AS feed_url,
+    users.name AS user_name,
+    (
+        SELECT COUNT(*) FROM posts
+        WHERE posts.feed_id = feed_follows.feed_id
+        AND NOT EXISTS (
+            SELECT 1 FROM post_reads
+            WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+        )
+    ) AS unread_count
 FROM feed_follows
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
 INNER JOIN users ON feed_follows.user_id = users.id
@@ -32,3 +41,7 @@ WHERE feed_follows.user_id = $1;
 -- name: DeleteFeedFollow :exec
 DELETE FROM feed_follows
 WHERE user_id = $1 AND feed_id = $2;
+
+-- name: SetFeedFollowCategory :exec
+UPDATE feed_follows SET category = $1
+WHERE user_id = $2 AND feed_id = $3;
@@ -1,5 +1,5 @@
--- name: CreatePost :exec
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+-- name: CreatePost :execrows
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content)
 VALUES (
     $1,
     $2,
@@ -8,13 +8,72 @@ VALUES (
     $5,
     $6,
     $7,
-    $8
-);
+    $8,
+    $9
+)
+ON CONFLICT (url) DO NOTHING;
 
--- name: GetPostsForUser :many
-SELECT posts.*
+-- name: GetRecentPostDates :many
+SELECT published_at FROM posts
+WHERE feed_id = $1 AND published_at IS NOT NULL
+ORDER BY published_at DESC
+LIMIT $2;
+
+-- name: GetPostByID :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, content FROM posts WHERE id = $1;
+
+-- name: GetPostByURL :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, content FROM posts WHERE url = $1;
+
+-- name: SearchPosts :many
+SELECT
+    posts.id,
+    posts.title,
+    posts.url,
+    posts.published_at,
+    feeds.name AS feed_name,
+    ts_rank(posts.search_vector, search_query) AS rank,
+    ts_headline(
+        'english',
+        coalesce(NULLIF(posts.description, ''), posts.content, posts.title),
+        search_query,
+        'StartSel=**, StopSel=**, MaxWords=35, MinWords=15, MaxFragments=2'
+    ) AS headline
+FROM posts
+CROSS JOIN websearch_to_tsquery('english', sqlc.arg(query)) AS search_query
+LEFT JOIN feeds ON posts.feed_id = feeds.id
+WHERE posts.search_vector @@ search_query
+AND (
+    sqlc.arg(all_feeds)::boolean
+    OR EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id
+        AND feed_follows.user_id = sqlc.arg(user_id)
+    )
+)
+ORDER BY rank DESC, posts.published_at DESC NULLS LAST
+LIMIT sqlc.arg(max_results);
+
+-- name: BrowsePosts :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content
 FROM posts
 INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
-WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
-LIMIT $2;
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id)::uuid)
+AND (sqlc.narg(since)::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg(since)::timestamp)
+AND (sqlc.narg(until)::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg(until)::timestamp)
+AND (
+    sqlc.narg(before_time)::timestamp IS NULL
+    OR (posts.published_at IS NOT NULL, COALESCE(posts.published_at, posts.created_at), posts.id)
+        < (sqlc.narg(before_dated)::boolean, sqlc.narg(before_time)::timestamp, sqlc.narg(before_id)::uuid)
+)
+AND (
+    NOT sqlc.arg(unread_only)::boolean
+    OR NOT EXISTS (
+        SELECT 1 FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+    )
+)
+-- Undated posts come last, by when they were stored
+ORDER BY posts.published_at IS NOT NULL DESC, COALESCE(posts.published_at, posts.created_at) DESC, posts.id DESC
+LIMIT sqlc.arg(max_posts);
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE feeds ADD last_error TEXT;
+ALTER TABLE feeds ADD last_error_status INTEGER;
+ALTER TABLE feeds ADD last_error_at TIMESTAMP;
+ALTER TABLE feeds ADD consecutive_failures INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE feeds DROP consecutive_failures;
+ALTER TABLE feeds DROP last_error_at;
+ALTER TABLE feeds DROP last_error_status;
+ALTER TABLE feeds DROP last_error;
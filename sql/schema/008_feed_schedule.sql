@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feeds ADD next_fetch_at TIMESTAMP;
+ALTER TABLE feeds ADD min_fetch_interval INTEGER;
+ALTER TABLE feeds ADD skip_hours INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD skip_days INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX feeds_next_fetch_at_idx ON feeds (next_fetch_at);
+
+-- +goose Down
+DROP INDEX feeds_next_fetch_at_idx;
+ALTER TABLE feeds DROP skip_days;
+ALTER TABLE feeds DROP skip_hours;
+ALTER TABLE feeds DROP min_fetch_interval;
+ALTER TABLE feeds DROP next_fetch_at;
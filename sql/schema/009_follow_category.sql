@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feed_follows ADD category TEXT;
+
+-- +goose Down
+ALTER TABLE feed_follows DROP category;
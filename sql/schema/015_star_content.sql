@@ -0,0 +1,6 @@
+-- +goose Up
+-- Stars keep the post content too, the post itself may be deleted
+ALTER TABLE stars ADD content TEXT;
+
+-- +goose Down
+ALTER TABLE stars DROP content;
@@ -0,0 +1,5 @@
+-- name: ResetDatabase :exec
+-- SQLite has no TRUNCATE, the foreign keys cascade the deletes instead
+DELETE FROM posts;
+DELETE FROM feeds;
+DELETE FROM users;
@@ -0,0 +1,76 @@
+-- name: CreatePost :execrows
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content)
+VALUES (
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    ?5,
+    ?6,
+    ?7,
+    ?8,
+    ?9
+)
+ON CONFLICT (url) DO NOTHING;
+
+-- name: GetRecentPostDates :many
+SELECT published_at FROM posts
+WHERE feed_id = ?1 AND published_at IS NOT NULL
+ORDER BY published_at DESC
+LIMIT ?2;
+
+-- name: GetPostByID :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, content FROM posts WHERE id = ?1;
+
+-- name: GetPostByURL :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, content FROM posts WHERE url = ?1;
+
+-- name: SearchPosts :many
+-- The query is rewritten from web search syntax into an FTS5 query before
+-- it gets here. The bm25 weights follow the search_vector weights.
+SELECT
+    posts.id,
+    posts.title,
+    posts.url,
+    posts.published_at,
+    feeds.name AS feed_name,
+    -bm25(posts_search, 0.0, 10.0, 4.0, 2.0) AS rank,
+    snippet(posts_search, -1, '**', '**', '…', 35) AS headline
+FROM posts_search
+INNER JOIN posts ON posts.id = posts_search.post_id
+LEFT JOIN feeds ON posts.feed_id = feeds.id
+WHERE posts_search MATCH ?1
+AND (
+    ?2
+    OR EXISTS (
+        SELECT 1 FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id
+        AND feed_follows.user_id = ?3
+    )
+)
+ORDER BY rank DESC, posts.published_at DESC NULLS LAST
+LIMIT ?4;
+
+-- name: BrowsePosts :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content
+FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = ?1
+AND (?2 IS NULL OR posts.feed_id = ?2)
+AND (?3 IS NULL OR COALESCE(posts.published_at, posts.created_at) >= ?3)
+AND (?4 IS NULL OR COALESCE(posts.published_at, posts.created_at) < ?4)
+AND (
+    ?5 IS NULL
+    OR (posts.published_at IS NOT NULL, COALESCE(posts.published_at, posts.created_at), posts.id)
+        < (?6, ?5, ?7)
+)
+AND (
+    NOT ?8
+    OR NOT EXISTS (
+        SELECT 1 FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+    )
+)
+-- Undated posts come last, by when they were stored
+ORDER BY posts.published_at IS NOT NULL DESC, COALESCE(posts.published_at, posts.created_at) DESC, posts.id DESC
+LIMIT ?9;
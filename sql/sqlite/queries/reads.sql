@@ -0,0 +1,42 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES (
+    ?1,
+    ?2,
+    ?3
+)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostUnread :exec
+DELETE FROM post_reads
+WHERE user_id = ?1 AND post_id = ?2;
+
+-- name: MarkFeedRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT ?1, posts.id, ?2
+FROM posts
+WHERE posts.feed_id = ?3
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkFeedUnread :execrows
+DELETE FROM post_reads
+WHERE post_reads.user_id = ?1
+AND post_reads.post_id IN (
+    SELECT id FROM posts WHERE posts.feed_id = ?2
+);
+
+-- name: MarkPostsReadBefore :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, ?1
+FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = ?2
+AND COALESCE(posts.published_at, posts.created_at) < ?3
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostsUnreadBefore :execrows
+DELETE FROM post_reads
+WHERE post_reads.user_id = ?1
+AND post_reads.post_id IN (
+    SELECT id FROM posts WHERE COALESCE(posts.published_at, posts.created_at) < ?2
+);
@@ -0,0 +1,30 @@
+-- name: CreateStar :one
+INSERT INTO stars (id, created_at, updated_at, user_id, post_id, title, url, description, published_at, feed_name, note, content)
+SELECT
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    posts.id,
+    posts.title,
+    posts.url,
+    posts.description,
+    posts.published_at,
+    feeds.name,
+    ?5,
+    posts.content
+FROM posts
+LEFT JOIN feeds ON posts.feed_id = feeds.id
+WHERE posts.id = ?6
+ON CONFLICT (user_id, url) DO UPDATE
+SET note = excluded.note, updated_at = excluded.updated_at
+RETURNING *;
+
+-- name: DeleteStar :execrows
+DELETE FROM stars
+WHERE user_id = ?1 AND url = ?2;
+
+-- name: GetStarsForUser :many
+SELECT * FROM stars
+WHERE user_id = ?1
+ORDER BY created_at DESC;
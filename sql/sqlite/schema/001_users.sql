@@ -0,0 +1,11 @@
+-- +goose Up
+-- UUIDs are stored as text in their canonical form
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    name TEXT UNIQUE NOT NULL
+);
+
+-- +goose Down
+DROP TABLE users;
@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE feeds (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    name TEXT UNIQUE,
+    url TEXT UNIQUE,
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    last_fetched_at TIMESTAMP,
+    etag TEXT,
+    last_modified TEXT,
+    last_error TEXT,
+    last_error_status INTEGER,
+    last_error_at TIMESTAMP,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    next_fetch_at TIMESTAMP,
+    min_fetch_interval INTEGER,
+    skip_hours INTEGER NOT NULL DEFAULT 0,
+    skip_days INTEGER NOT NULL DEFAULT 0,
+    description TEXT,
+    site_url TEXT,
+    icon_url TEXT
+);
+CREATE INDEX feeds_next_fetch_at_idx ON feeds (next_fetch_at);
+
+-- +goose Down
+DROP INDEX feeds_next_fetch_at_idx;
+DROP TABLE feeds;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE feed_follows (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    feed_id TEXT REFERENCES feeds(id) ON DELETE CASCADE NOT NULL,
+    category TEXT,
+    UNIQUE(user_id, feed_id)
+);
+
+-- +goose Down
+DROP TABLE feed_follows;
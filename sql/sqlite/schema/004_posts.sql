@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE posts (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    title TEXT NOT NULL,
+    url TEXT UNIQUE NOT NULL,
+    description TEXT,
+    published_at TIMESTAMP,
+    feed_id TEXT REFERENCES feeds(id) ON DELETE CASCADE,
+    content TEXT
+);
+CREATE INDEX posts_feed_published_idx ON posts (feed_id, (COALESCE(published_at, created_at)) DESC, id DESC);
+
+-- +goose Down
+DROP INDEX posts_feed_published_idx;
+DROP TABLE posts;
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE post_reads (
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    post_id TEXT REFERENCES posts(id) ON DELETE CASCADE NOT NULL,
+    read_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE post_reads;
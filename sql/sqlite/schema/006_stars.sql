@@ -0,0 +1,19 @@
+-- +goose Up
+-- Stars snapshot the post so they survive the post or its feed being deleted
+CREATE TABLE stars (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    post_id TEXT REFERENCES posts(id) ON DELETE SET NULL,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    description TEXT,
+    published_at TIMESTAMP,
+    feed_name TEXT,
+    note TEXT,
+    UNIQUE(user_id, url)
+);
+
+-- +goose Down
+DROP TABLE stars;
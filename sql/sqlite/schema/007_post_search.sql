@@ -0,0 +1,38 @@
+-- +goose Up
+-- Full text search over posts, in place of the search_vector column in
+-- Postgres. The index keeps its own copy of the text, kept in sync by
+-- triggers, and is joined to posts on post_id.
+CREATE VIRTUAL TABLE posts_search USING fts5(
+    post_id UNINDEXED,
+    title,
+    description,
+    content,
+    tokenize = 'porter unicode61'
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_search_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_search (post_id, title, description, content)
+    VALUES (new.id, new.title, new.description, new.content);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_search_update AFTER UPDATE ON posts BEGIN
+    DELETE FROM posts_search WHERE post_id = old.id;
+    INSERT INTO posts_search (post_id, title, description, content)
+    VALUES (new.id, new.title, new.description, new.content);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_search_delete AFTER DELETE ON posts BEGIN
+    DELETE FROM posts_search WHERE post_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER posts_search_delete;
+DROP TRIGGER posts_search_update;
+DROP TRIGGER posts_search_insert;
+DROP TABLE posts_search;